@@ -7,6 +7,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/wangkang/fortify/files"
 	"github.com/wangkang/fortify/fortifier"
+	"github.com/wangkang/fortify/utils"
 )
 
 func init() {
@@ -24,12 +25,17 @@ Required Arguments:
 Required Arguments:
   <key1>   Path to the first secret share file or private key file if cipher key kind of <input-file> is 'rsa'
   [key2]   [Required cipher key kind of <input-file> is 'sss'] Path to the second secret share file
-  ...      Additional paths to secret share files (all files remain unmodified)
+  ...      Additional paths to secret share files (all files remain unmodified); secret share files
+           may also be given as directories or glob patterns, of which only the fewest files of the set
+           of <input-file> meeting its threshold are used
 `, c.UsageTemplate()))
 	root.AddCommand(c)
 	initFlagHelp(c)
 	initFlagTruncate(c)
 	initFlagVerbose(c)
+	initFlagPassphrase(c)
+	initFlagDealerKey(c)
+	initFlagCommitments(c)
 	initFlagIn(c, "[Required] Path of the fortified/encrypted input file")
 	_ = c.MarkFlagRequired("in")
 	c.Flags().StringVarP(&o, "out", "o", "output.data", "Path of the output decrypted file")
@@ -37,6 +43,15 @@ Required Arguments:
 
 func decrypt(input, output string, args []string) (err error) {
 	files.SetVerbose(flagVerbose)
+	if err = utils.SetPassphraseSource(flagPassphrase); err != nil {
+		return
+	}
+	if err = setupDealerKeys(); err != nil {
+		return
+	}
+	if err = setupCommitments(); err != nil {
+		return
+	}
 	var in, out *os.File
 	var iCloseFn, oCloseFn func()
 	if in, iCloseFn, err = files.OpenInputFile(input); err != nil {
@@ -55,6 +70,7 @@ func decrypt(input, output string, args []string) (err error) {
 	if f, _, err = newFortifier(meta.Key, meta, args); err != nil {
 		return
 	}
+	defer f.Close()
 	var dec fortifier.Decrypter
 	if dec = fortifier.NewDecrypter(meta.Mode, f); dec == nil {
 		err = fmt.Errorf("unknown cipher mode name: %s", meta.Mode)
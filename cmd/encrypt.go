@@ -1,12 +1,15 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
 	"github.com/wangkang/fortify/files"
 	"github.com/wangkang/fortify/fortifier"
+	"github.com/wangkang/fortify/sss"
+	"github.com/wangkang/fortify/utils"
 )
 
 var flagEncOut, flagEncKey, flagEncMode string
@@ -29,6 +32,7 @@ Required Arguments:
 	initFlagHelp(c)
 	initFlagTruncate(c)
 	initFlagVerbose(c)
+	initFlagPolicy(c)
 	initFlagIn(c, "[Required] Path of the input file")
 	_ = c.MarkFlagRequired("in")
 	c.Flags().StringVarP(&flagEncOut, "out", "o", "fortified.data",
@@ -37,17 +41,40 @@ Required Arguments:
 		"Cipher key kind name, options: [sss|rsa]")
 	c.Flags().StringVarP(&flagEncMode, "mode", "m", fortifier.CipherModeAes256CTR.String(),
 		"Cipher mode name, options: [aes256-ctr|aes256-ofb|aes256-cfb]")
+	initFlagLabels(c)
+	initFlagSeal(c)
+	initFlagPassphrase(c)
+	initFlagSigningKey(c)
+	initFlagDealerKey(c)
+	c.Flags().IntVar(&flagMinSecretLengthBits, "min-secret-length-bits", fortifier.DefaultMinSecretLengthBits,
+		"Minimum length in bits of the secret combined from the key parts")
 }
 
 func encrypt(input, output, key, mode string, args []string) (err error) {
 	files.SetVerbose(flagVerbose)
+	if err = utils.SetPassphraseSource(flagPassphrase); err != nil {
+		return
+	}
+	if err = setupDealerKeys(); err != nil {
+		return
+	}
+	var labels sss.Labels
+	if labels, err = keyPartLabels(); err != nil {
+		return
+	}
+	if len(args) > 0 && (len(labels.Labels) > 0 || len(labels.Holders) > 0 || labels.NotAfter != nil || flagSeal ||
+		len(flagSigningKey) > 0) {
+		return errors.New("--label, --holder, --not-after, --seal and --sign-key apply to generated key parts only")
+	}
 	var f *fortifier.Fortifier
 	if f, _, err = newFortifier(fortifier.CipherKeyKind(key), nil, args); err != nil {
 		return
 	}
+	defer f.Close()
+	f.SetKeyPartLabels(labels)
+	f.SetKeyPartSealing(flagSeal)
 	var enc fortifier.Encrypter
-	if enc = fortifier.NewEncrypter(fortifier.CipherModeName(mode), f); enc == nil {
-		err = fmt.Errorf("unknown cipher mode name: %s", mode)
+	if enc, err = fortifier.NewEncrypter(fortifier.CipherModeName(mode), f); err != nil {
 		return
 	}
 	var in, out *os.File
@@ -12,6 +12,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/wangkang/fortify/files"
 	"github.com/wangkang/fortify/fortifier"
+	"github.com/wangkang/fortify/utils"
 )
 
 func init() {
@@ -25,17 +26,31 @@ func init() {
 Required Arguments:
   <key1>   Path to the first secret share file or private key file if cipher key kind of <input-file> is 'rsa'
   [key2]   [Required cipher key kind of <input-file> is 'sss'] Path to the second secret share file
-  ...      Additional paths to secret share files (all files remain unmodified)
+  ...      Additional paths to secret share files (all files remain unmodified); secret share files
+           may also be given as directories or glob patterns, of which only the fewest files of the set
+           of <input-file> meeting its threshold are used
 `, c.UsageTemplate()))
 	root.AddCommand(c)
 	initFlagHelp(c)
 	initFlagVerbose(c)
+	initFlagPassphrase(c)
+	initFlagDealerKey(c)
+	initFlagCommitments(c)
 	initFlagIn(c, "[Required] Path of the fortified/encrypted input file")
 	_ = c.MarkFlagRequired("in")
 }
 
 func execute(input string, args []string) (err error) {
 	files.SetVerbose(flagVerbose)
+	if err = utils.SetPassphraseSource(flagPassphrase); err != nil {
+		return
+	}
+	if err = setupDealerKeys(); err != nil {
+		return
+	}
+	if err = setupCommitments(); err != nil {
+		return
+	}
 	var in *os.File
 	var iCloseFn func()
 	if in, iCloseFn, err = files.OpenInputFile(input); err != nil {
@@ -53,6 +68,7 @@ func execute(input string, args []string) (err error) {
 	if f, rest, err = newFortifier(meta.Key, meta, args); err != nil {
 		return
 	}
+	defer f.Close()
 	var dec fortifier.Decrypter
 	if dec = fortifier.NewDecrypter(meta.Mode, f); dec == nil {
 		err = fmt.Errorf("unknown cipher mode name: %s", meta.Mode)
@@ -68,7 +84,9 @@ func execute(input string, args []string) (err error) {
 	//started := time.Now()
 	//fmt.Printf("%s *-->O %s %d bytes [%s %s]\n", in.Name(), out.Name(), layout.DataLength(), meta.Key, meta.Mode)
 	r := bufio.NewReaderSize(in, 128*1024)
-	if err = dec.Decrypt(r, out, layout); err != nil {
+	err = dec.Decrypt(r, out, layout)
+	f.Close() // the key is not needed anymore, and os.Exit skips deferred calls
+	if err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "Failed to decrypt program: %v\n", err)
 		os.Exit(1)
 		return nil
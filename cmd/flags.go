@@ -1,7 +1,12 @@
 package cmd
 
 import (
+	"os"
+
 	"github.com/spf13/cobra"
+	"github.com/wangkang/fortify/fortifier"
+	"github.com/wangkang/fortify/sss"
+	"github.com/wangkang/fortify/utils"
 )
 
 const (
@@ -11,13 +16,24 @@ const (
 )
 
 var (
-	flagVerbose      bool
-	flagTruncate     bool
-	flagIn           string
-	flagPrefix       string
-	flagBytes        int
-	flagSssParts     uint8 = defaultSssParts
-	flagSssThreshold uint8 = defaultSssThreshold
+	flagVerbose             bool
+	flagTruncate            bool
+	flagResume              bool
+	flagIn                  string
+	flagPrefix              string
+	flagBytes               int
+	flagSssParts            uint8 = defaultSssParts
+	flagSssThreshold        uint8 = defaultSssThreshold
+	flagPassphrase                = utils.PassphraseSource{Fd: -1}
+	flagMinSecretLengthBits       = fortifier.DefaultMinSecretLengthBits
+	flagPolicy              string
+	flagLabels              []string
+	flagHolders             []string
+	flagNotAfter            string
+	flagSeal                bool
+	flagSigningKey          string
+	flagDealerKey           string
+	flagSssCommitments      string
 )
 
 func initFlagVerbose(c *cobra.Command) {
@@ -29,6 +45,10 @@ func initFlagTruncate(c *cobra.Command) {
 	c.Flags().BoolVarP(&flagTruncate, "truncate", "T", false, "Truncate the output file(s) before write")
 }
 
+func initFlagResume(c *cobra.Command, usage string) {
+	c.Flags().BoolVar(&flagResume, "resume", false, usage)
+}
+
 func initFlagHelp(c *cobra.Command) {
 	c.Flags().BoolP("help", "h", false, "Show help message")
 }
@@ -51,3 +71,98 @@ func initFlagPrefix(c *cobra.Command, usage string) {
 func initFlagBytes(c *cobra.Command, value int, usage string) {
 	c.Flags().IntVarP(&flagBytes, "bytes", "b", value, usage)
 }
+
+func initFlagPassphrase(c *cobra.Command) {
+	c.Flags().StringVar(&flagPassphrase.File, "passphrase-file", "",
+		"Read the private key or key part passphrases from the first line of this file")
+	c.Flags().IntVar(&flagPassphrase.Fd, "passphrase-fd", -1,
+		"Read the private key or key part passphrases from this open file descriptor, one line each")
+	c.Flags().StringVar(&flagPassphrase.Env, "passphrase-env", "",
+		"Read the private key or key part passphrases from this environment variable")
+	c.Flags().StringVar(&flagPassphrase.Askpass, "askpass", "",
+		"Run this program (like SSH_ASKPASS) to obtain the private key or key part passphrases")
+}
+
+func initFlagPolicy(c *cobra.Command) {
+	c.Flags().StringVar(&flagPolicy, "policy", "",
+		"Path of the security policy file (default $"+fortifier.PolicyEnv+" or <user-config-dir>/fortify/policy.json)")
+}
+
+func initFlagLabels(c *cobra.Command) {
+	c.Flags().StringArrayVar(&flagLabels, "label", nil,
+		"Free-form label written into every generated secret share (repeatable)")
+	c.Flags().StringArrayVar(&flagHolders, "holder", nil,
+		"Holder name and email, e.g. 'Alice <alice@example.com>', of each generated secret share in order (repeatable)")
+	c.Flags().StringVar(&flagNotAfter, "not-after", "",
+		"Date (2006-01-02 or RFC 3339) after which the generated secret shares are reported as expired")
+}
+
+func initFlagSeal(c *cobra.Command) {
+	c.Flags().BoolVar(&flagSeal, "seal", false,
+		"Seal each generated secret share with a passphrase of its holder, asked for every share in order")
+}
+
+func initFlagSigningKey(c *cobra.Command) {
+	c.Flags().StringVar(&flagSigningKey, "sign-key", "",
+		"Path of the ed25519 private key (e.g. id_ed25519) of the dealer, signing every generated secret share")
+}
+
+func initFlagDealerKey(c *cobra.Command) {
+	c.Flags().StringVar(&flagDealerKey, "dealer-key", "",
+		"Path of the ed25519 public key (e.g. id_ed25519.pub) of the trusted dealer, whose signature every secret share must carry")
+}
+
+func initFlagCommitments(c *cobra.Command) {
+	c.Flags().StringVarP(&flagSssCommitments, "commitments", "c", "",
+		"Path of the published commitments file, to check verifiable secret shares against instead of the commitments they carry")
+}
+
+// setupCommitments loads the published commitments given by --commitments.
+func setupCommitments() error {
+	if len(flagSssCommitments) == 0 {
+		return nil
+	}
+	c, err := sss.ReadCommitmentsFile(flagSssCommitments)
+	if err != nil {
+		return err
+	}
+	sss.SetCommitments(c)
+	return nil
+}
+
+// setupDealerKeys loads the keys given by --sign-key and --dealer-key.
+func setupDealerKeys() error {
+	if len(flagSigningKey) > 0 {
+		content, err := os.ReadFile(flagSigningKey)
+		if err != nil {
+			return err
+		}
+		key, err := sss.ParseSigningKey(content)
+		utils.Wipe(content)
+		if err != nil {
+			return err
+		}
+		sss.SetSigningKey(key)
+	}
+	if len(flagDealerKey) > 0 {
+		content, err := os.ReadFile(flagDealerKey)
+		if err != nil {
+			return err
+		}
+		key, err := sss.ParseDealerKey(content)
+		if err != nil {
+			return err
+		}
+		sss.SetDealerKey(key)
+	}
+	return nil
+}
+
+func keyPartLabels() (l sss.Labels, err error) {
+	l.Labels = flagLabels
+	l.Holders = flagHolders
+	if len(flagNotAfter) > 0 {
+		l.NotAfter, err = sss.ParseNotAfter(flagNotAfter)
+	}
+	return
+}
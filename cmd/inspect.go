@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wangkang/fortify/files"
+	"github.com/wangkang/fortify/fortifier"
+	"github.com/wangkang/fortify/sss"
+)
+
+func init() {
+	c := &cobra.Command{
+		Short: "Print the metadata of the fortified input file",
+		Use:   "inspect -i <input-file> [flags]",
+		Args:  cobra.NoArgs,
+		RunE:  func(_ *cobra.Command, _ []string) error { return inspect(flagIn) },
+	}
+	root.AddCommand(c)
+	initFlagHelp(c)
+	initFlagVerbose(c)
+	initFlagPolicy(c)
+	initFlagIn(c, "[Required] Path of the fortified/encrypted input file")
+	_ = c.MarkFlagRequired("in")
+}
+
+func inspect(input string) (err error) {
+	files.SetVerbose(flagVerbose)
+	var policy *fortifier.Policy
+	if policy, err = loadPolicy(); err != nil {
+		return
+	}
+	var in *os.File
+	var iCloseFn func()
+	if in, iCloseFn, err = files.OpenInputFile(input); err != nil {
+		return
+	}
+	defer iCloseFn()
+	layout := &fortifier.FileLayout{}
+	if err = layout.ReadHeadIn(in); err != nil {
+		return
+	}
+	if flagVerbose {
+		fmt.Printf("%s\n", layout.String())
+	}
+	meta := layout.Metadata()
+	printField("Version", string(layout.Version()))
+	printField("Data Length", layout.DataLength())
+	printField("Timestamp", meta.Timestamp)
+	printField("Key", meta.Key)
+	printField("Mode", meta.Mode)
+	if m := meta.Sss; m != nil {
+		printField("SSS Set", orNotRecorded(m.Set))
+		printField("SSS Parts", m.Parts)
+		printField("SSS Threshold", m.Threshold)
+		if len(m.Weights) > 0 {
+			printField("SSS Weights", formatWeights(m.Weights))
+		}
+		if len(m.Groups) > 0 {
+			printField("SSS Groups", fmt.Sprintf("%d of groups %s", m.GroupThreshold, m.Groups))
+		}
+		printField("SSS Digest", m.Digest)
+	}
+	if m := meta.Rsa; m != nil {
+		printField("RSA Fingerprint", orNotRecorded(m.Fingerprint))
+		if m.Bits > 0 {
+			printField("RSA Bits", m.Bits)
+		} else {
+			printField("RSA Bits", orNotRecorded(""))
+		}
+		printField("RSA Digest", m.Digest)
+	}
+	for _, v := range policy.Violations(meta) {
+		fmt.Printf("VIOLATION: %v\n", v)
+	}
+	return
+}
+
+func printField(name string, value any) {
+	fmt.Printf("%-16s %v\n", name+":", value)
+}
+
+func orNotRecorded(s string) string {
+	if len(s) == 0 {
+		return "(not recorded)"
+	}
+	return s
+}
+
+// formatWeights lists the weight of every part toward the threshold, such as "part 1: 2, part 2: 1".
+func formatWeights(weights string) string {
+	ws, err := sss.ParseWeights(weights)
+	if err != nil {
+		return weights
+	}
+	s := make([]string, len(ws))
+	for i, w := range ws {
+		s[i] = fmt.Sprintf("part %d: %d", i+1, w)
+	}
+	return strings.Join(s, ", ")
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// writeRsaKeys writes a new RSA key pair into the directory, and returns the paths of the public
+// key in the authorized keys format and of the private key in PEM along with the fingerprint.
+func writeRsaKeys(t *testing.T, dir, name string) (pub, pri, fingerprint string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	public, err := ssh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	pub, pri = filepath.Join(dir, name+".pub"), filepath.Join(dir, name)
+	if err = os.WriteFile(pub, ssh.MarshalAuthorizedKey(public), 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	if err = os.WriteFile(pri, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return pub, pri, ssh.FingerprintSHA256(public)
+}
+
+// captureStdout returns what fn prints to the standard output.
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	printed := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(r)
+		printed <- b
+	}()
+	err = fn()
+	_ = w.Close()
+	return string(<-printed), err
+}
+
+func TestInspect_fingerprint(t *testing.T) {
+	dir := t.TempDir()
+	policy := filepath.Join(dir, "policy.json")
+	if err := os.WriteFile(policy, []byte("{}"), 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	flagPolicy = policy
+	t.Cleanup(func() { flagPolicy = "" })
+	pub, pri, fingerprint := writeRsaKeys(t, dir, "id")
+	_, other, _ := writeRsaKeys(t, dir, "other")
+	in, fortified := filepath.Join(dir, "in.data"), filepath.Join(dir, "fortified.data")
+	secret := []byte("test inspect")
+	if err := os.WriteFile(in, secret, 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := encrypt(in, fortified, "rsa", "aes256-ctr", []string{pub}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	printed, err := captureStdout(t, func() error { return inspect(fortified) })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expect := "RSA Fingerprint: " + fingerprint + "\n"
+	if !strings.Contains(printed, expect) || !strings.Contains(printed, "RSA Bits:        2048\n") {
+		t.Fatalf("expect %q, actual %q", expect, printed)
+	}
+
+	// The private key of another fingerprint is refused.
+	out := filepath.Join(dir, "out.data")
+	if err = decrypt(fortified, out, []string{other}); err == nil || !strings.Contains(err.Error(), fingerprint) {
+		t.Fatalf("expect error naming %s, actual %v", fingerprint, err)
+	}
+	if err = decrypt(fortified, out, []string{pri}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	decrypted, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(decrypted, secret) {
+		t.Fatalf("expect %q, actual %q", secret, decrypted)
+	}
+}
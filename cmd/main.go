@@ -1,6 +1,10 @@
 package cmd
 
+import "github.com/wangkang/fortify/sss"
+
 func Execute() int {
+	// The dealer signing key, if given, is wiped once the command is done.
+	defer sss.SetSigningKey(nil)
 	if err := root.Execute(); err == nil {
 		return 0
 	} else {
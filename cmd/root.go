@@ -21,18 +21,36 @@ func init() {
 func newFortifier(
 	kind fortifier.CipherKeyKind, meta *fortifier.Metadata, args []string,
 ) (*fortifier.Fortifier, []string, error) {
+	var policy *fortifier.Policy
+	if meta == nil {
+		var err error
+		if policy, err = loadPolicy(); err != nil {
+			return nil, args, err
+		}
+		if err = policy.CheckKeyKind(kind); err != nil {
+			return nil, args, err
+		}
+	}
 	switch kind {
 	case fortifier.CipherKeyKindSSS:
-		if parts, err := sss.CombineKeyFiles(args); err != nil {
+		var digest, set string
+		if meta != nil && meta.Sss != nil {
+			digest, set = meta.Sss.Digest, meta.Sss.Set
+		}
+		paths, consumed, err := sss.DiscoverKeyParts(args, digest, set, false, flagVerbose)
+		if err != nil {
+			return nil, args, err
+		}
+		if parts, err := sss.CombineKeyFiles(paths); err != nil {
 			return nil, args, err
 		} else {
-			return fortifier.NewFortifierWithSss(flagVerbose, flagTruncate, parts), args[len(parts):], nil
+			return fortifier.NewFortifierWithSss(flagVerbose, flagTruncate, policy, meta, parts), args[consumed:], nil
 		}
 	case fortifier.CipherKeyKindRSA:
 		if kb, err := readKeyFile(args); err != nil {
 			return nil, args, err
 		} else {
-			return fortifier.NewFortifierWithRsa(flagVerbose, meta, kb), args[1:], nil
+			return fortifier.NewFortifierWithRsa(flagVerbose, policy, meta, kb), args[1:], nil
 		}
 	default:
 		return nil, args, fmt.Errorf("unknown cipher key kind: %s", kind)
@@ -55,3 +73,16 @@ func readKeyFile(args []string) (kb []byte, err error) {
 	}
 	return
 }
+
+func loadPolicy() (*fortifier.Policy, error) {
+	path := flagPolicy
+	if len(path) == 0 {
+		path = fortifier.DefaultPolicyPath()
+	}
+	policy, err := fortifier.LoadPolicy(path)
+	if err != nil {
+		return nil, err
+	}
+	policy.MinSecretLengthBits = max(policy.MinSecretLengthBits, flagMinSecretLengthBits)
+	return policy, nil
+}
@@ -8,36 +8,63 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/wangkang/fortify/files"
 	"github.com/wangkang/fortify/sss"
+	"github.com/wangkang/fortify/utils"
 )
 
-var flagSssCombineOut string
+var (
+	flagSssCombineOut    string
+	flagSssCombineRobust bool
+)
 
 func init() {
 	c := &cobra.Command{
 		RunE:  sssCombineRunE,
 		Use:   "combine -o <output-file> [flags] <input-file1> <input-file2> ...",
 		Short: "Combine secret shares to recover the original data",
-		Args:  cobra.MinimumNArgs(2),
+		Args:  cobra.MinimumNArgs(1),
 	}
 	c.SetUsageTemplate(fmt.Sprintf(`%s
 Required Arguments:
-  <input-file1>      Path to the first secret share file
+  <input-file1>      Path to the first secret share file, or a directory or glob pattern of secret share files
   <input-file2>      Path to the second secret share file
-  ...                Additional paths to secret share files (at least two required; all files remain unmodified)
+  ...                Additional paths to secret share files (all files remain unmodified); of the files found in
+                     directories and by patterns, only the fewest of one set meeting its threshold are combined
 `, c.UsageTemplate()))
 	initFlagHelp(c)
 	initFlagTruncate(c)
+	initFlagResume(c, "Resume an interrupted combine from <output-file>.checkpoint.json, with the same input files")
 	initFlagVerbose(c)
+	initFlagPassphrase(c)
+	initFlagDealerKey(c)
+	initFlagCommitments(c)
 	c.Flags().StringVarP(&flagSssCombineOut, "out", "o", "",
 		"[Required] Specify the output file for the recovered original data")
+	c.Flags().BoolVar(&flagSssCombineRobust, "robust", false,
+		"Recover from the consistent shares when more than the threshold are given, naming the corrupted ones")
 	ssss.AddCommand(c)
 }
 
 func sssCombineRunE(_ *cobra.Command, args []string) error {
 	files.SetVerbose(flagVerbose)
+	if err := utils.SetPassphraseSource(flagPassphrase); err != nil {
+		return err
+	}
+	if err := setupDealerKeys(); err != nil {
+		return err
+	}
+	if err := setupCommitments(); err != nil {
+		return err
+	}
 	file := strings.TrimSpace(flagSssCombineOut)
 	if len(file) == 0 {
 		return errors.New("empty path of the output file")
 	}
-	return sss.CombinePartFiles(args, file, flagTruncate, flagVerbose)
+	paths, consumed, err := sss.DiscoverKeyParts(args, "", "", flagSssCombineRobust, flagVerbose)
+	if err != nil {
+		return err
+	}
+	if consumed < len(args) {
+		return fmt.Errorf("cannot open input file: %s", args[consumed])
+	}
+	return sss.CombinePartFiles(paths, file, flagResume, flagTruncate, flagVerbose, flagSssCombineRobust)
 }
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/wangkang/fortify/files"
+	"github.com/wangkang/fortify/sss"
+	"github.com/wangkang/fortify/utils"
+)
+
+var flagSssExtendPart int
+
+func init() {
+	c := &cobra.Command{
+		RunE:  sssExtendRunE,
+		Use:   "extend [flags] <input-file1> <input-file2> ...",
+		Short: "Issue an additional secret share for an existing set of secret shares",
+		Args:  cobra.MinimumNArgs(2),
+	}
+	c.SetUsageTemplate(fmt.Sprintf(`%s
+Required Arguments:
+  <input-file1>      Path to the first secret share file
+  <input-file2>      Path to the second secret share file
+  ...                Additional paths to secret share files (at least threshold required; all files remain unmodified)
+                     Include the most recently issued share to avoid reusing its x coordinate
+`, c.UsageTemplate()))
+	ssss.AddCommand(c)
+	initFlagHelp(c)
+	initFlagTruncate(c)
+	initFlagVerbose(c)
+	initFlagPassphrase(c)
+	initFlagSigningKey(c)
+	initFlagDealerKey(c)
+	initFlagPrefix(c, "File path prefix for the new secret share")
+	c.Flags().IntVar(&flagSssExtendPart, "part", 0,
+		"Number of the new secret share (default next after the highest known number)")
+}
+
+func sssExtendRunE(_ *cobra.Command, args []string) (err error) {
+	defer sss.CloseAllFilesForWrite()
+	files.SetVerbose(flagVerbose)
+	if err = utils.SetPassphraseSource(flagPassphrase); err != nil {
+		return
+	}
+	if err = setupDealerKeys(); err != nil {
+		return
+	}
+	var parts []sss.Part
+	if parts, err = sss.CombineKeyFiles(args); err != nil {
+		return
+	}
+	var p sss.Part
+	if p, err = sss.Extend(parts, flagSssExtendPart); err != nil {
+		return
+	}
+	if err = sss.AppendParts([]sss.Part{p}, 0, 1, flagPrefix, flagTruncate); err != nil {
+		return
+	}
+	if flagVerbose {
+		fmt.Printf("Issued part %d of set %s (threshold %d)\n", p.Part, p.Set, p.Threshold)
+	}
+	return
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wangkang/fortify/files"
+	"github.com/wangkang/fortify/sss"
+	"github.com/wangkang/fortify/utils"
+)
+
+const (
+	sssVault = "vault"
+	sssSsss  = "ssss"
+)
+
+var (
+	flagSssImportFrom  string
+	flagSssExportTo    string
+	flagSssExportJson  bool
+	flagSssNoDiffusion bool
+)
+
+func init() {
+	c := &cobra.Command{
+		RunE:  sssImportRunE,
+		Use:   "import --from <vault|ssss> [flags] <key-file1> ...",
+		Short: "Convert HashiCorp Vault unseal keys or ssss shares into secret shares",
+		Args:  cobra.MinimumNArgs(1),
+	}
+	c.SetUsageTemplate(fmt.Sprintf(`%s
+Required Arguments:
+  <key-file1>        File holding the output of vault operator init, in text or JSON, or unseal keys in base64 or
+                     hex, one per line, or "-" to read them from the standard input; keys are never read from
+                     the arguments, which other users can see
+                     With --from ssss: file of ssss shares written as [token-]index-hex, one per line, or "-"
+  ...                Additional files
+`, c.UsageTemplate()))
+	ssss.AddCommand(c)
+	initFlagHelp(c)
+	initFlagTruncate(c)
+	initFlagVerbose(c)
+	initFlagPassphrase(c)
+	initFlagSigningKey(c)
+	initFlagPartsAndThreshold(c)
+	initFlagPrefix(c, "File path prefix for the generated secret shares")
+	initFlagLabels(c)
+	c.Flags().StringVar(&flagSssImportFrom, "from", sssVault,
+		"Origin of the keys, options: [vault|ssss]; -p/-t are required unless taken from the JSON output of vault operator init")
+	c.Flags().BoolVarP(&flagSssNoDiffusion, "no-diffusion", "D", false,
+		"With --from ssss: the shares were split by ssss-split -D, without the diffusion layer")
+
+	c = &cobra.Command{
+		RunE:  sssExportRunE,
+		Use:   "export --to <vault|ssss> [flags] <input-file1> ...",
+		Short: "Print secret shares as HashiCorp Vault unseal keys or ssss shares",
+		Args:  cobra.MinimumNArgs(1),
+	}
+	c.SetUsageTemplate(fmt.Sprintf(`%s
+Required Arguments:
+  <input-file1>      Path to the first secret share file, imported from Vault or split without blinding
+                     With --to ssss: at least threshold secret share files of any kind, to split the secret again
+  ...                Additional paths to secret share files (all files remain unmodified)
+`, c.UsageTemplate()))
+	ssss.AddCommand(c)
+	initFlagHelp(c)
+	initFlagVerbose(c)
+	initFlagPassphrase(c)
+	initFlagDealerKey(c)
+	c.Flags().StringVar(&flagSssExportTo, "to", sssVault, "Destination of the secret shares, options: [vault|ssss]")
+	c.Flags().BoolVar(&flagSssExportJson, "json", false,
+		"Print the unseal keys as JSON, like vault operator init -format=json, instead of text")
+	c.Flags().BoolVarP(&flagSssNoDiffusion, "no-diffusion", "D", false,
+		"With --to ssss: split without the diffusion layer, like ssss-split -D")
+}
+
+func sssImportRunE(c *cobra.Command, args []string) (err error) {
+	defer sss.CloseAllFilesForWrite()
+	files.SetVerbose(flagVerbose)
+	if err = utils.SetPassphraseSource(flagPassphrase); err != nil {
+		return
+	}
+	if err = setupDealerKeys(); err != nil {
+		return
+	}
+	if flagSssImportFrom != sssVault && flagSssImportFrom != sssSsss {
+		return fmt.Errorf("unknown origin of secret shares: %s", flagSssImportFrom)
+	}
+	var labels sss.Labels
+	if labels, err = keyPartLabels(); err != nil {
+		return
+	}
+	parts, threshold := flagSssParts, flagSssThreshold
+	given := c.Flags().Changed("parts") && c.Flags().Changed("threshold")
+	var keys []string
+	stdin := false
+	for _, arg := range args {
+		var content []byte
+		if arg == "-" {
+			if stdin {
+				return errors.New("the standard input can be read only once")
+			}
+			stdin = true
+			content, err = io.ReadAll(os.Stdin)
+		} else if content, err = os.ReadFile(arg); errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no such key file: %s (keys are read from files or the standard input only)", arg)
+		}
+		if err != nil {
+			return
+		}
+		if flagSssImportFrom == sssSsss {
+			var shares []string
+			shares, err = sss.ParseSsssShares(string(content))
+			keys = append(keys, shares...)
+		} else {
+			var vk sss.VaultKeys
+			vk, err = sss.ParseVaultKeys(string(content))
+			keys = append(keys, vk.KeysB64...)
+			if vk.Shares > 0 {
+				parts, threshold, given = vk.Shares, vk.Threshold, true
+			}
+		}
+		utils.Wipe(content)
+		if err != nil {
+			return
+		}
+	}
+	if !given {
+		// Imported parts carry no digest, so a wrong threshold would go unnoticed until the secret is used.
+		return fmt.Errorf("give the number of shares and the threshold of the %s keys with -p and -t", flagSssImportFrom)
+	}
+	var ps []sss.Part
+	if flagSssImportFrom == sssSsss {
+		ps, err = sss.ImportSsss(keys, parts, threshold, !flagSssNoDiffusion)
+	} else {
+		ps, err = sss.ImportVault(keys, parts, threshold)
+	}
+	if err != nil {
+		return
+	}
+	if err = labels.Apply(ps); err != nil {
+		return
+	}
+	if err = sss.AppendParts(ps, 0, 1, flagPrefix, flagTruncate); err != nil {
+		return
+	}
+	if flagVerbose {
+		fmt.Printf("Imported %d %s keys as parts of %d with threshold %d\n", len(ps), flagSssImportFrom, parts, threshold)
+	}
+	return
+}
+
+func sssExportRunE(_ *cobra.Command, args []string) (err error) {
+	files.SetVerbose(flagVerbose)
+	if err = utils.SetPassphraseSource(flagPassphrase); err != nil {
+		return
+	}
+	if err = setupDealerKeys(); err != nil {
+		return
+	}
+	if flagSssExportTo != sssVault && flagSssExportTo != sssSsss {
+		return fmt.Errorf("unknown destination of secret shares: %s", flagSssExportTo)
+	}
+	var parts []sss.Part
+	if parts, err = sss.CombineKeyFiles(args); err != nil {
+		return
+	}
+	if len(parts) != len(args) {
+		return fmt.Errorf("cannot open input file: %s", args[len(parts)])
+	}
+	if flagSssExportTo == sssSsss {
+		var shares []string
+		if shares, err = sss.ExportSsss(parts, !flagSssNoDiffusion); err != nil {
+			return
+		}
+		for _, share := range shares {
+			fmt.Println(share)
+		}
+		return
+	}
+	var keys sss.VaultKeys
+	if keys, err = sss.ExportVault(parts, args); err != nil {
+		return
+	}
+	if flagSssExportJson {
+		var content []byte
+		if content, err = json.MarshalIndent(keys, "", "  "); err != nil {
+			return
+		}
+		fmt.Println(string(content))
+		return
+	}
+	for i, key := range keys.KeysB64 {
+		fmt.Printf("Unseal Key %d: %s\n", keys.Numbers[i], key)
+	}
+	fmt.Printf("\nUnseal threshold: %d of %d\n", keys.Threshold, keys.Shares)
+	return
+}
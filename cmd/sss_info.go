@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wangkang/fortify/sss"
+)
+
+var flagSssInfoJson bool
+
+func init() {
+	c := &cobra.Command{
+		RunE:  sssInfoRunE,
+		Use:   "info [flags] <input-file1> [input-file2] ...",
+		Short: "Tell whether secret share files belong together and reach their threshold, without combining them",
+		Args:  cobra.MinimumNArgs(1),
+	}
+	c.SetUsageTemplate(fmt.Sprintf(`%s
+Required Arguments:
+  <input-file1>      Path to the first secret share file, a directory of them, or a glob pattern such as "shares/*.json"
+  ...                Additional paths to secret share files (all files remain unmodified)
+`, c.UsageTemplate()))
+	ssss.AddCommand(c)
+	initFlagHelp(c)
+	initFlagDealerKey(c)
+	c.Flags().BoolVar(&flagSssInfoJson, "json", false, "Print the report as JSON")
+}
+
+func sssInfoRunE(_ *cobra.Command, args []string) (err error) {
+	if err = setupDealerKeys(); err != nil {
+		return
+	}
+	var report sss.Report
+	if report, err = sss.InspectKeyParts(args); err != nil {
+		return
+	}
+	if flagSssInfoJson {
+		var content []byte
+		if content, err = json.MarshalIndent(report, "", "  "); err != nil {
+			return
+		}
+		fmt.Println(string(content))
+	} else {
+		printInfoReport(report)
+	}
+	if !report.Reachable() {
+		return errors.New("no set of the secret share files reaches its threshold")
+	}
+	return nil
+}
+
+func printInfoReport(report sss.Report) {
+	for _, f := range report.Files {
+		if len(f.Error) > 0 {
+			fmt.Printf("%s: %s\n", f.Path, strings.ReplaceAll(f.Error, "\n", " "))
+			continue
+		}
+		s := fmt.Sprintf("part %d of %d", f.Part, f.Parts)
+		if f.Group > 0 {
+			s = fmt.Sprintf("part %d of %d in group %d", f.Part, f.Parts, f.Group)
+		}
+		if f.Weight > 1 {
+			s += fmt.Sprintf(", weight %d", f.Weight)
+		}
+		s += fmt.Sprintf(", %d/%d blocks", f.BlocksFound, f.Blocks)
+		if len(f.Holder) > 0 {
+			s += ", held by " + f.Holder
+		}
+		if f.Sealed {
+			s += ", sealed"
+		}
+		if len(f.Dealer) > 0 {
+			s += ", signed by " + f.Dealer
+		}
+		if f.Expired {
+			s += ", expired on " + f.NotAfter.Format(time.DateOnly)
+		}
+		fmt.Printf("%s: %s\n", f.Path, s)
+	}
+	for _, s := range report.Sets {
+		fmt.Println()
+		printField("Set", orNotRecorded(s.Set))
+		printField("Digest", orNotRecorded(s.Digest))
+		if len(s.Scheme) > 0 {
+			printField("Scheme", s.Scheme)
+		}
+		if len(s.Groups) > 0 {
+			printField("Groups", fmt.Sprintf("%s, any %d of them", s.Groups, s.GroupThreshold))
+		} else {
+			printField("Threshold", fmt.Sprintf("%d of %d", s.Threshold, s.Parts))
+		}
+		printField("Blocks", s.Blocks)
+		printField("Files", fmt.Sprintf("%d, %d usable", len(s.Files), s.Usable))
+		for _, d := range s.Duplicates {
+			name := fmt.Sprintf("part %d", d.Part)
+			if d.Group > 0 {
+				name = fmt.Sprintf("part %d of group %d", d.Part, d.Group)
+			}
+			printField("Duplicate", fmt.Sprintf("%s in %s", name, strings.Join(d.Files, ", ")))
+		}
+		for _, p := range s.Problems {
+			printField("Problem", p)
+		}
+		if s.Reachable {
+			printField("Reachable", "yes")
+		} else {
+			printField("Reachable", "no, "+s.Shortfall)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/wangkang/fortify/files"
+	"github.com/wangkang/fortify/sss"
+)
+
+var (
+	flagSssKitFormat  string
+	flagSssKitHolders []string
+)
+
+func init() {
+	c := &cobra.Command{
+		RunE:  sssExportKitRunE,
+		Use:   "export-kit [flags] <input-file1> ...",
+		Short: "Render secret shares as printable recovery kit pages with QR codes",
+		Args:  cobra.MinimumNArgs(1),
+	}
+	c.SetUsageTemplate(fmt.Sprintf(`%s
+Required Arguments:
+  <input-file1>      Path to the first secret share file
+  ...                Additional paths to secret share files (all files remain unmodified)
+`, c.UsageTemplate()))
+	ssss.AddCommand(c)
+	initFlagHelp(c)
+	initFlagTruncate(c)
+	initFlagVerbose(c)
+	initFlagDealerKey(c)
+	initFlagPrefix(c, "File path prefix for the recovery kit pages (<prefix>kitNofM.html or .svg)")
+	c.Flags().StringVar(&flagSssKitFormat, "format", sss.KitFormatHtml, "Format of the recovery kit pages: html or svg")
+	c.Flags().StringArrayVar(&flagSssKitHolders, "holder", nil,
+		"Holder printed on the page of each input share in order, instead of the holder in the share (repeatable)")
+}
+
+func sssExportKitRunE(_ *cobra.Command, args []string) (err error) {
+	defer sss.CloseAllFilesForWrite()
+	files.SetVerbose(flagVerbose)
+	if err = setupDealerKeys(); err != nil {
+		return
+	}
+	if len(flagSssKitHolders) > len(args) {
+		return fmt.Errorf("%d holders given for %d secret shares", len(flagSssKitHolders), len(args))
+	}
+	var parts []sss.Part
+	if parts, err = sss.ReadSealedKeyFiles(args); err != nil {
+		return
+	}
+	if len(parts) != len(args) {
+		return fmt.Errorf("cannot open input file: %s", args[len(parts)])
+	}
+	for i, p := range parts {
+		var holder string
+		if i < len(flagSssKitHolders) {
+			holder = flagSssKitHolders[i]
+		}
+		path := fmt.Sprintf("%skit%dof%d.%s", flagPrefix, p.Part, p.Parts, flagSssKitFormat)
+		if err = sss.WriteKitFile(p, holder, flagSssKitFormat, path, flagTruncate); err != nil {
+			return
+		}
+		if flagVerbose {
+			fmt.Printf("Recovery kit of part %d of set %s: %s\n", p.Part, p.Set, path)
+		}
+	}
+	return
+}
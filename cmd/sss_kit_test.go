@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wangkang/fortify/sss"
+	"github.com/wangkang/fortify/utils"
+)
+
+func TestSssExportKit_sealed(t *testing.T) {
+	t.Cleanup(func() {
+		flagPrefix, flagTruncate, flagSssKitFormat = "", false, sss.KitFormatHtml
+		_ = utils.SetPassphraseSource(utils.PassphraseSource{Fd: -1})
+	})
+	dir := t.TempDir()
+	secret := []byte("test sealed kit")
+	ps, err := sss.Split(secret, 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	plain := ps[0]
+	for i, passphrase := range []string{"first passphrase", "second passphrase"} {
+		path := filepath.Join(dir, fmt.Sprintf("passphrase%d", i+1))
+		if err = os.WriteFile(path, []byte(passphrase+"\n"), 0600); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if err = utils.SetPassphraseSource(utils.PassphraseSource{File: path, Fd: -1}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if err = sss.SealParts(ps[i : i+1]); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	if err = sss.AppendParts(ps, 0, 1, filepath.Join(dir, "key"), true); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	sss.CloseAllFilesForWrite()
+	var keys []string
+	for _, p := range ps {
+		keys = append(keys, filepath.Join(dir, fmt.Sprintf("key%dof%d.json", p.Part, p.Parts)))
+	}
+
+	// No passphrase is asked for: the standard input of tests is not a terminal.
+	if err = utils.SetPassphraseSource(utils.PassphraseSource{Fd: -1}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	flagPrefix, flagTruncate, flagSssKitFormat = filepath.Join(dir, "out-"), true, sss.KitFormatSvg
+	if err = sssExportKitRunE(nil, keys); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	sss.CloseAllFilesForWrite()
+
+	// The kit of a sealed part is the kit of the part as sealed, not of its plain share.
+	for _, c := range []struct {
+		part   sss.Part
+		sealed bool
+	}{{ps[0], true}, {plain, false}} {
+		expect := filepath.Join(dir, fmt.Sprintf("expect%d.svg", c.part.Part))
+		if err = sss.WriteKitFile(c.part, "", sss.KitFormatSvg, expect, true); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		sss.CloseAllFilesForWrite()
+		expected, _ := os.ReadFile(expect)
+		actual, err := os.ReadFile(flagPrefix + "kit1of3.svg")
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if bytes.Equal(expected, actual) != c.sealed {
+			t.Fatalf("expect the kit of the part sealed %v", c.sealed)
+		}
+		if c.sealed && !strings.Contains(string(actual), "Sealed: ") {
+			t.Fatalf("expect the kit marked sealed")
+		}
+	}
+
+	// The scanned texts of the sealed kits are unsealed with the passphrases of their holders.
+	var scans []string
+	for _, p := range ps[:2] {
+		text, err := sss.KitText(p)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		scan := filepath.Join(dir, fmt.Sprintf("scan%d.txt", p.Part))
+		if err = os.WriteFile(scan, []byte(text), 0600); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		scans = append(scans, scan)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	if _, err = w.WriteString("first passphrase\nsecond passphrase\n"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	_ = w.Close()
+	if err = utils.SetPassphraseSource(utils.PassphraseSource{Fd: int(r.Fd())}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	parts, err := sss.CombineKeyFiles(scans)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	recombined, err := sss.Combine(parts)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recombined, secret) {
+		t.Fatalf("expect %q, actual %q", secret, recombined)
+	}
+}
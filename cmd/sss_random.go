@@ -2,11 +2,26 @@ package cmd
 
 import (
 	"crypto/rand"
+	"errors"
 	"fmt"
 
 	"github.com/spf13/cobra"
 	"github.com/wangkang/fortify/files"
 	"github.com/wangkang/fortify/sss"
+	"github.com/wangkang/fortify/utils"
+)
+
+var (
+	flagSssVerifiable     bool
+	flagSssFormat         string
+	flagSssGroups         string
+	flagSssWeights        string
+	flagSssGroupThreshold uint8
+)
+
+const (
+	sssFormatJson  = "json"
+	sssFormatWords = "words"
 )
 
 func init() {
@@ -22,22 +37,137 @@ func init() {
 	initFlagPartsAndThreshold(c)
 	initFlagPrefix(c, "File path prefix for the generated secret shares")
 	initFlagBytes(c, defaultRandomBytes, "Length of the randomly generated byte array")
+	initFlagLabels(c)
+	initFlagSeal(c)
+	initFlagPassphrase(c)
+	initFlagSigningKey(c)
+	c.Flags().BoolVar(&flagSssVerifiable, "verifiable", false,
+		"Split with Feldman commitments, written into <prefix>commitments.json, to make each share verifiable")
+	c.Flags().StringVar(&flagSssFormat, "format", sssFormatJson,
+		"Format of the secret share files: json, or words (<prefix>NofM.txt, to be copied by hand)")
+	c.Flags().StringVar(&flagSssWeights, "weights", "",
+		"Weights of the secret shares toward the threshold, e.g. 2,1,1,1 to count the first share twice (ignores -p)")
+	c.Flags().StringVar(&flagSssGroups, "groups", "",
+		"Split among groups written as <threshold>of<parts>, e.g. 2of3,3of5, into <prefix>gK-NofM.json (ignores -p/-t)")
+	c.Flags().Uint8Var(&flagSssGroupThreshold, "group-threshold", 0,
+		"Number of groups required for secret recovery with --groups (default all groups)")
 }
 
 func sssRandomRunE(_ *cobra.Command, _ []string) (err error) {
 	defer sss.CloseAllFilesForWrite()
 	files.SetVerbose(flagVerbose)
+	if err = utils.SetPassphraseSource(flagPassphrase); err != nil {
+		return
+	}
+	if err = setupDealerKeys(); err != nil {
+		return
+	}
+	if flagSssFormat != sssFormatJson && flagSssFormat != sssFormatWords {
+		return fmt.Errorf("unknown secret share format: %s", flagSssFormat)
+	}
+	if flagSssFormat == sssFormatWords && flagSssVerifiable {
+		return errors.New("verifiable secret shares cannot be written as words")
+	}
+	if len(flagSigningKey) > 0 && flagSssFormat != sssFormatJson {
+		return errors.New("signed secret shares can only be written as json")
+	}
+	if flagSeal && (flagSssFormat != sssFormatJson || flagSssVerifiable) {
+		return errors.New("sealed secret shares can only be written as json without --verifiable")
+	}
 	var bs = uint16(flagBytes)
 	if bs == 0 || int(bs) != flagBytes {
 		return fmt.Errorf("value of flag (--bytes / -b) is out of range (0,65535]: %d", flagBytes)
 	}
+	var labels sss.Labels
+	if labels, err = keyPartLabels(); err != nil {
+		return
+	}
 	secret := make([]byte, bs)
 	if _, err = rand.Reader.Read(secret); err != nil {
 		return
 	}
+	if len(flagSssGroups) > 0 {
+		return sssRandomGroups(secret, labels)
+	}
 	var ps []sss.Part
-	if ps, err = sss.Split(secret, flagSssParts, flagSssThreshold); err != nil {
+	if len(flagSssWeights) > 0 {
+		if flagSssVerifiable || flagSssFormat != sssFormatJson {
+			return errors.New("weighted secret shares can only be written as json without --verifiable")
+		}
+		var weights []uint8
+		if weights, err = sss.ParseWeights(flagSssWeights); err != nil {
+			return
+		}
+		ps, err = sss.SplitWeighted(secret, weights, flagSssThreshold)
+	} else if flagSssVerifiable {
+		ps, err = sss.SplitVerifiable(secret, flagSssParts, flagSssThreshold)
+	} else {
+		ps, err = sss.Split(secret, flagSssParts, flagSssThreshold)
+	}
+	if err != nil {
+		return
+	}
+	if err = labels.Apply(ps); err != nil {
 		return
 	}
-	return sss.AppendParts(ps, 0, 1, flagPrefix, flagTruncate)
+	if flagSeal {
+		if err = sss.SealParts(ps); err != nil {
+			return
+		}
+	}
+	if flagSssFormat == sssFormatWords {
+		for _, p := range ps {
+			p.Block, p.Blocks = 1, 1
+			if err = sss.WriteWordsFile(p, fmt.Sprintf("%s%dof%d.txt", flagPrefix, p.Part, p.Parts), flagTruncate); err != nil {
+				return
+			}
+		}
+		return
+	}
+	if err = sss.AppendParts(ps, 0, 1, flagPrefix, flagTruncate); err != nil {
+		return
+	}
+	if flagSssVerifiable {
+		return sss.WriteCommitmentsFile(ps, flagPrefix+"commitments.json", flagTruncate)
+	}
+	return
+}
+
+func sssRandomGroups(secret []byte, labels sss.Labels) (err error) {
+	if flagSssVerifiable || flagSssFormat != sssFormatJson {
+		return errors.New("group secret shares can only be written as json without --verifiable")
+	}
+	var groups []sss.Group
+	if groups, err = sss.ParseGroups(flagSssGroups); err != nil {
+		return
+	}
+	threshold := flagSssGroupThreshold
+	if threshold == 0 {
+		threshold = uint8(min(len(groups), 255))
+	}
+	var gs [][]sss.Part
+	if gs, err = sss.SplitGroups(secret, threshold, groups); err != nil {
+		return
+	}
+	// The holders are given in the order of the groups and their members.
+	var all []sss.Part
+	for _, ps := range gs {
+		all = append(all, ps...)
+	}
+	if err = labels.Apply(all); err != nil {
+		return
+	}
+	if flagSeal {
+		if err = sss.SealParts(all); err != nil {
+			return
+		}
+	}
+	for i, ps := range gs {
+		ps = all[:len(ps)]
+		all = all[len(ps):]
+		if err = sss.AppendParts(ps, 0, 1, fmt.Sprintf("%sg%d-", flagPrefix, i+1), flagTruncate); err != nil {
+			return
+		}
+	}
+	return
 }
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/wangkang/fortify/files"
+	"github.com/wangkang/fortify/sss"
+	"github.com/wangkang/fortify/utils"
+)
+
+func init() {
+	c := &cobra.Command{
+		RunE:  sssReshareRunE,
+		Use:   "reshare [flags] <input-file1> <input-file2> ...",
+		Short: "Issue a new set of secret shares for the same secret, invalidating none of the fortified files",
+		Args:  cobra.MinimumNArgs(2),
+	}
+	c.SetUsageTemplate(fmt.Sprintf(`%s
+Required Arguments:
+  <input-file1>      Path to the first secret share file
+  <input-file2>      Path to the second secret share file
+  ...                Additional paths to secret share files (at least threshold required; all files remain unmodified)
+`, c.UsageTemplate()))
+	ssss.AddCommand(c)
+	initFlagHelp(c)
+	initFlagTruncate(c)
+	initFlagVerbose(c)
+	initFlagPassphrase(c)
+	initFlagSigningKey(c)
+	initFlagDealerKey(c)
+	initFlagPartsAndThreshold(c)
+	initFlagPrefix(c, "File path prefix for the new secret shares")
+}
+
+func sssReshareRunE(c *cobra.Command, args []string) (err error) {
+	defer sss.CloseAllFilesForWrite()
+	files.SetVerbose(flagVerbose)
+	if err = utils.SetPassphraseSource(flagPassphrase); err != nil {
+		return
+	}
+	if err = setupDealerKeys(); err != nil {
+		return
+	}
+	var parts []sss.Part
+	if parts, err = sss.CombineKeyFiles(args); err != nil {
+		return
+	}
+	var newParts, newThreshold uint8
+	if c.Flags().Changed("parts") {
+		newParts = flagSssParts
+	}
+	if c.Flags().Changed("threshold") {
+		newThreshold = flagSssThreshold
+	}
+	var ps []sss.Part
+	if ps, err = sss.Reshare(parts, newParts, newThreshold); err != nil {
+		return
+	}
+	if err = sss.AppendParts(ps, 0, 1, flagPrefix, flagTruncate); err != nil {
+		return
+	}
+	if flagVerbose {
+		fmt.Printf("Reshared set %s into set %s (%d parts, threshold %d)\n",
+			parts[0].Set, ps[0].Set, ps[0].Parts, ps[0].Threshold)
+	}
+	return
+}
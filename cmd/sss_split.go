@@ -8,8 +8,11 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/wangkang/fortify/files"
 	"github.com/wangkang/fortify/sss"
+	"github.com/wangkang/fortify/utils"
 )
 
+var flagSssIda bool
+
 func init() {
 	c := &cobra.Command{
 		RunE:  sssSplitRunE,
@@ -24,15 +27,26 @@ Arguments:
 	ssss.AddCommand(c)
 	initFlagHelp(c)
 	initFlagVerbose(c)
+	initFlagPassphrase(c)
+	initFlagSigningKey(c)
 	initFlagTruncate(c)
+	initFlagResume(c, "Resume an interrupted split from <prefix>checkpoint.json, with the same input file and flags")
 	initFlagPartsAndThreshold(c)
 	initFlagIn(c, "[Required if no [input-file]] Path of the input file")
 	initFlagPrefix(c, "File path prefix for the generated secret shares")
+	c.Flags().BoolVar(&flagSssIda, "ida", false,
+		"Encrypt the input file with a random key split into the shares, and disperse the ciphertext so that each share is about the size of the input file / threshold")
 }
 
 func sssSplitRunE(_ *cobra.Command, args []string) error {
 	defer sss.CloseAllFilesForWrite()
 	files.SetVerbose(flagVerbose)
+	if err := utils.SetPassphraseSource(flagPassphrase); err != nil {
+		return err
+	}
+	if err := setupDealerKeys(); err != nil {
+		return err
+	}
 	file := strings.TrimSpace(flagIn)
 	if len(file) == 0 && len(args) > 0 {
 		file = strings.TrimSpace(args[0])
@@ -40,5 +54,5 @@ func sssSplitRunE(_ *cobra.Command, args []string) error {
 	if len(file) == 0 {
 		return errors.New("empty path of the input file")
 	}
-	return sss.SplitIntoFiles(file, flagSssParts, flagSssThreshold, flagPrefix, flagTruncate, flagVerbose)
+	return sss.SplitIntoFiles(file, flagSssParts, flagSssThreshold, flagPrefix, flagSssIda, flagResume, flagTruncate, flagVerbose)
 }
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/wangkang/fortify/files"
+	"github.com/wangkang/fortify/sss"
+	"github.com/wangkang/fortify/utils"
+)
+
+func init() {
+	c := &cobra.Command{
+		RunE:  sssVerifyShareRunE,
+		Use:   "verify-share [flags] <input-file1> [input-file2] ...",
+		Short: "Verify secret shares against the Feldman commitments of their set",
+		Args:  cobra.MinimumNArgs(1),
+	}
+	c.SetUsageTemplate(fmt.Sprintf(`%s
+Required Arguments:
+  <input-file1>      Path to the first verifiable secret share file
+  ...                Additional paths to verifiable secret share files (all files remain unmodified)
+`, c.UsageTemplate()))
+	ssss.AddCommand(c)
+	initFlagHelp(c)
+	initFlagVerbose(c)
+	initFlagPassphrase(c)
+	initFlagDealerKey(c)
+	c.Flags().StringVarP(&flagSssCommitments, "commitments", "c", "",
+		"Path of the published commitments file (default the commitments carried by each share)")
+}
+
+func sssVerifyShareRunE(_ *cobra.Command, args []string) (err error) {
+	files.SetVerbose(flagVerbose)
+	if err = utils.SetPassphraseSource(flagPassphrase); err != nil {
+		return
+	}
+	if err = setupDealerKeys(); err != nil {
+		return
+	}
+	var commitments *sss.Commitments
+	if len(flagSssCommitments) > 0 {
+		if commitments, err = sss.ReadCommitmentsFile(flagSssCommitments); err != nil {
+			return
+		}
+	}
+	var parts []sss.Part
+	if parts, err = sss.CombineKeyFiles(args); err != nil {
+		return
+	}
+	failed := 0
+	for i, p := range parts {
+		if err = sss.VerifyPart(p, commitments); err != nil {
+			failed++
+			fmt.Printf("%s: FAILED -- %v\n", args[i], err)
+		} else {
+			fmt.Printf("%s: OK (part %d of set %s)\n", args[i], p.Part, p.Set)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d secret shares failed verification", failed, len(parts))
+	}
+	if len(parts) < len(args) {
+		return errors.New("not all arguments are secret share files")
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package files
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -65,6 +66,48 @@ func OpenOutputFile(name string, truncate bool, flags ...int) (file *os.File, cl
 	return
 }
 
+// ResumeOutputFile opens an output file written up to size bytes by an interrupted run, discards
+// anything written beyond, and positions it at its end to go on writing.
+func ResumeOutputFile(name string, size int64) (file *os.File, closeFn func(), err error) {
+	var path string
+	if path, err = filepath.Abs(strings.TrimSpace(name)); err != nil {
+		return
+	}
+	if file, err = os.OpenFile(path, os.O_WRONLY, 0600); err != nil {
+		return
+	}
+	if err = AcquireExclusiveLock(file.Fd()); err != nil {
+		_ = file.Close()
+		return nil, nil, err
+	}
+	var stat os.FileInfo
+	if stat, err = file.Stat(); err == nil && stat.Size() < size {
+		err = fmt.Errorf("%s is shorter than the %d bytes already written", path, size)
+	}
+	if err == nil {
+		if err = file.Truncate(size); err == nil {
+			_, err = file.Seek(size, io.SeekStart)
+		}
+	}
+	if err != nil {
+		_ = ReleaseLock(file.Fd())
+		_ = file.Close()
+		return nil, nil, err
+	}
+	if verbose {
+		fmt.Printf("%s <-- resume at %d bytes\n", file.Name(), size)
+	}
+	closeFn = func() {
+		_ = file.Sync()
+		_ = ReleaseLock(file.Fd())
+		_ = file.Close()
+		if verbose {
+			fmt.Printf("%s <-- close\n", file.Name())
+		}
+	}
+	return
+}
+
 func openForRead(name string) (*os.File, error) {
 	var (
 		err  error
@@ -83,6 +83,9 @@ type Aes256StreamDecrypter struct {
 }
 
 func (f *Aes256StreamDecrypter) DecryptFile(in, out *os.File, layout *FileLayout, mode CipherMode) (err error) {
+	if err = f.checkSssKey(layout.Metadata().Sss); err != nil {
+		return
+	}
 	if err = f.SetupKey(); err != nil {
 		return
 	}
@@ -105,6 +108,9 @@ func (f *Aes256StreamDecrypter) DecryptFile(in, out *os.File, layout *FileLayout
 }
 
 func (f *Aes256StreamDecrypter) Decrypt(in io.Reader, w io.Writer, layout *FileLayout, mode CipherMode) (err error) {
+	if err = f.checkSssKey(layout.Metadata().Sss); err != nil {
+		return
+	}
 	if err = f.SetupKey(); err != nil {
 		return
 	}
@@ -117,9 +123,6 @@ func (f *Aes256StreamDecrypter) Decrypt(in io.Reader, w io.Writer, layout *FileL
 	if meta.Mode != mode.Name {
 		return fmt.Errorf("requires cipher mode: %s", meta.Mode)
 	}
-	if f.meta.Sss != nil && meta.Sss.Digest != f.meta.Sss.Digest {
-		return errors.New("mismatched key digest")
-	}
 	f.meta.Mode = meta.Mode
 	f.meta.Timestamp = meta.Timestamp
 	iv := make([]byte, f.block.BlockSize())
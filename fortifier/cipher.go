@@ -4,12 +4,10 @@ import (
 	"crypto/cipher"
 	"crypto/hmac"
 	"crypto/sha256"
-	"fmt"
 	"hash"
-	"os"
 
 	"github.com/wangkang/fortify/sss"
-	"golang.org/x/term"
+	"github.com/wangkang/fortify/utils"
 )
 
 type CipherKeyKind string
@@ -29,12 +27,31 @@ type CipherKey interface {
 }
 
 type CipherKeyData struct {
-	kind  CipherKeyKind
-	raw   []byte
-	parts []sss.Part
-	bytes []byte
+	kind   CipherKeyKind
+	raw    []byte
+	secure *utils.SecureBuffer
+	parts  []sss.Part
+	bytes  []byte
 }
 
+// setRaw moves the cipher key into locked memory and wipes the given slice.
+func (k *CipherKeyData) setRaw(raw []byte) {
+	k.secure.Destroy()
+	k.secure = utils.NewSecureBufferFrom(raw)
+	k.raw = k.secure.Bytes()
+}
+
+// destroy wipes the cipher key and the key file content.
+func (k *CipherKeyData) destroy() {
+	k.secure.Destroy()
+	k.secure = nil
+	k.raw = nil
+	utils.Wipe(k.bytes)
+	k.bytes = nil
+}
+
+// NewSha256 returns an HMAC-SHA256 keyed with the cipher key. The padded copies of the key made by
+// crypto/hmac live on the ordinary heap, and are not wiped.
 func (k *CipherKeyData) NewSha256() hash.Hash {
 	return hmac.New(sha256.New, k.raw)
 }
@@ -59,14 +76,3 @@ const (
 	CipherModeAes256OFB CipherModeName = "aes256-ofb"
 	CipherModeAes256CFB CipherModeName = "aes256-cfb"
 )
-
-func enterPassphrase() []byte {
-	fmt.Print("Enter passphrase: ")
-	if passphrase, err := term.ReadPassword(int(os.Stdin.Fd())); err != nil {
-		fmt.Printf("\nError reading passphrase: %v\n", err)
-		return nil
-	} else {
-		fmt.Println()
-		return passphrase
-	}
-}
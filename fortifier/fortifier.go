@@ -3,9 +3,12 @@ package fortifier
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"fmt"
 	"io"
 	"os"
 	"time"
+
+	"github.com/wangkang/fortify/sss"
 )
 
 type Encrypter interface {
@@ -30,19 +33,25 @@ type Fortifier struct {
 	key      *CipherKeyData
 	verbose  bool
 	truncate bool
+	policy   *Policy
+	labels   sss.Labels
+	seal     bool
 	block    cipher.Block
 }
 
-func NewEncrypter(mode CipherModeName, f *Fortifier) Encrypter {
+func NewEncrypter(mode CipherModeName, f *Fortifier) (Encrypter, error) {
+	if err := f.policy.CheckMode(mode); err != nil {
+		return nil, err
+	}
 	switch mode {
 	case CipherModeAes256CTR:
-		return NewAes256EncrypterCTR(f)
+		return NewAes256EncrypterCTR(f), nil
 	case CipherModeAes256OFB:
-		return NewAes256EncrypterOFB(f)
+		return NewAes256EncrypterOFB(f), nil
 	case CipherModeAes256CFB:
-		return NewAes256EncrypterCFB(f)
+		return NewAes256EncrypterCFB(f), nil
 	default:
-		return nil
+		return nil, fmt.Errorf("unknown cipher mode name: %s", mode)
 	}
 }
 
@@ -77,3 +86,14 @@ func (f *Fortifier) SetupKey() (err error) {
 	}
 	return
 }
+
+// Close wipes the cipher key and the key file content held by the fortifier. The AES key schedule
+// of the block cipher lives on the ordinary heap of crypto/aes, out of reach: Close only drops it,
+// and it is not wiped. It is safe to call more than once.
+func (f *Fortifier) Close() {
+	if f == nil || f.key == nil {
+		return
+	}
+	f.key.destroy()
+	f.block = nil
+}
@@ -9,6 +9,7 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"math/big"
 	"reflect"
 	"time"
 
@@ -19,12 +20,14 @@ import (
 const rsaFortifier = "rsa_fortifier"
 
 type MetadataRsa struct {
-	Timestamp  time.Time `json:"timestamp"`
-	Digest     string    `json:"digest"`
-	Ciphertext string    `json:"ciphertext"`
+	Timestamp   time.Time `json:"timestamp"`
+	Digest      string    `json:"digest"`
+	Ciphertext  string    `json:"ciphertext"`
+	Fingerprint string    `json:"fingerprint,omitempty"`
+	Bits        int       `json:"bits,omitempty"`
 }
 
-func NewFortifierWithRsa(verbose bool, meta *Metadata, bytes []byte) *Fortifier {
+func NewFortifierWithRsa(verbose bool, policy *Policy, meta *Metadata, bytes []byte) *Fortifier {
 	var m *MetadataRsa
 	if meta != nil {
 		m = meta.Rsa
@@ -33,6 +36,7 @@ func NewFortifierWithRsa(verbose bool, meta *Metadata, bytes []byte) *Fortifier
 		meta:    &Metadata{Rsa: m},
 		key:     &CipherKeyData{kind: CipherKeyKindRSA, bytes: bytes},
 		verbose: verbose,
+		policy:  policy,
 	}
 }
 
@@ -76,6 +80,13 @@ func (f *Fortifier) setupRsaPublicKey() (err error) {
 	if pub == nil {
 		return
 	}
+	if err = f.policy.CheckRsaBits(pub.N.BitLen()); err != nil {
+		return
+	}
+	var fingerprint string
+	if fingerprint, err = rsaFingerprint(pub); err != nil {
+		return
+	}
 	raw := make([]byte, 32)
 	if _, err = rand.Read(raw); err != nil {
 		return
@@ -84,13 +95,16 @@ func (f *Fortifier) setupRsaPublicKey() (err error) {
 	if encrypted, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, raw, nil); err != nil {
 		return
 	}
-	f.key.raw = raw
 	f.meta.Key = CipherKeyKindRSA
 	f.meta.Timestamp = time.Now()
+	digest := utils.ComputeDigest(raw)
+	f.key.setRaw(raw)
 	f.meta.Rsa = &MetadataRsa{
-		Timestamp:  time.Now(),
-		Digest:     utils.ComputeDigest(raw),
-		Ciphertext: base64.URLEncoding.EncodeToString(encrypted),
+		Timestamp:   time.Now(),
+		Digest:      digest,
+		Ciphertext:  base64.URLEncoding.EncodeToString(encrypted),
+		Fingerprint: fingerprint,
+		Bits:        pub.N.BitLen(),
 	}
 	return
 }
@@ -100,12 +114,24 @@ func (f *Fortifier) setupRsaPrivateKey() (err error) {
 	if pri, err = f.parseRsaPrivateKey(); err != nil {
 		return
 	}
+	defer wipeRsaPrivateKey(pri)
 	m := f.meta.Rsa
+	if len(m.Fingerprint) > 0 {
+		var actual string
+		if actual, err = rsaFingerprint(&pri.PublicKey); err != nil {
+			return
+		}
+		if m.Fingerprint != actual {
+			return fmt.Errorf("%s: this file requires key %s, you supplied %s", rsaFortifier, m.Fingerprint, actual)
+		}
+	}
 	var ciphertext []byte
 	ciphertext, err = base64.URLEncoding.DecodeString(m.Ciphertext)
-	if f.key.raw, err = rsa.DecryptOAEP(sha256.New(), rand.Reader, pri, ciphertext, nil); err != nil {
+	var raw []byte
+	if raw, err = rsa.DecryptOAEP(sha256.New(), rand.Reader, pri, ciphertext, nil); err != nil {
 		return fmt.Errorf("%s: decrypting secret key failed. %v", rsaFortifier, err)
 	}
+	f.key.setRaw(raw)
 	actual := utils.ComputeDigest(f.key.raw)
 	if m.Digest != actual {
 		return fmt.Errorf("%s: digest mismatch. expect %q, actual %q", rsaFortifier, m.Digest, actual)
@@ -120,7 +146,12 @@ func (f *Fortifier) parseRsaPrivateKey() (*rsa.PrivateKey, error) {
 	if k, err = ssh.ParseRawPrivateKey(bytes); err != nil {
 		var passphraseMissingError *ssh.PassphraseMissingError
 		if errors.As(err, &passphraseMissingError) {
-			k, err = ssh.ParseRawPrivateKeyWithPassphrase(bytes, enterPassphrase())
+			var passphrase []byte
+			if passphrase, err = utils.ReadPassphrase("Enter passphrase: "); err != nil {
+				return nil, fmt.Errorf("%s: %v", rsaFortifier, err)
+			}
+			k, err = ssh.ParseRawPrivateKeyWithPassphrase(bytes, passphrase)
+			utils.Wipe(passphrase)
 		}
 	}
 	if err != nil {
@@ -156,3 +187,33 @@ func (f *Fortifier) decodePemFile() (blocks []pem.Block) {
 	}
 	return
 }
+
+// rsaFingerprint computes the OpenSSH-style SHA-256 fingerprint of an RSA public key.
+func rsaFingerprint(pub *rsa.PublicKey) (string, error) {
+	k, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", rsaFortifier, err)
+	}
+	return ssh.FingerprintSHA256(k), nil
+}
+
+// wipeRsaPrivateKey overwrites the private values of the key once it is no longer needed.
+func wipeRsaPrivateKey(k *rsa.PrivateKey) {
+	wipe := func(n *big.Int) {
+		if n != nil {
+			clear(n.Bits())
+		}
+	}
+	wipe(k.D)
+	for _, p := range k.Primes {
+		wipe(p)
+	}
+	wipe(k.Precomputed.Dp)
+	wipe(k.Precomputed.Dq)
+	wipe(k.Precomputed.Qinv)
+	for _, v := range k.Precomputed.CRTValues {
+		wipe(v.Exp)
+		wipe(v.Coeff)
+		wipe(v.R)
+	}
+}
@@ -2,62 +2,192 @@ package fortifier
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
 	"time"
 
 	"github.com/wangkang/fortify/sss"
+	"github.com/wangkang/fortify/utils"
+	"golang.org/x/crypto/hkdf"
 )
 
+const sssFortifier = "sss_fortifier"
+
+// DefaultMinSecretLengthBits is the minimum length in bits of a combined secret accepted for
+// encryption unless the policy demands more. Only the length is checked, not the entropy: a long
+// secret of few random bits, such as a split passphrase, passes.
+const DefaultMinSecretLengthBits = 256
+
+// sssKdfHkdfSha256 derives the AES-256 key from the combined secret with HKDF-SHA256.
+// Files without a KDF name in their metadata use the combined secret as the key directly.
+const sssKdfHkdfSha256 = "hkdf-sha256"
+
+var sssKdfInfo = []byte("fortify aes256 key")
+
 type MetadataSss struct {
-	Timestamp time.Time `json:"timestamp"`
-	Digest    string    `json:"digest"`
-	Parts     uint8     `json:"parts"`
-	Threshold uint8     `json:"threshold"`
+	Timestamp      time.Time `json:"timestamp"`
+	Digest         string    `json:"digest"`
+	Set            string    `json:"set,omitempty"`
+	Parts          uint8     `json:"parts"`
+	Threshold      uint8     `json:"threshold"`
+	Groups         string    `json:"groups,omitempty"`
+	GroupThreshold uint8     `json:"group_threshold,omitempty"`
+	Weights        string    `json:"weights,omitempty"`
+	Kdf            string    `json:"kdf,omitempty"`
+	Salt           string    `json:"salt,omitempty"`
 }
 
-func NewFortifierWithSss(verbose, truncate bool, parts []sss.Part) *Fortifier {
+func NewFortifierWithSss(verbose, truncate bool, policy *Policy, meta *Metadata, parts []sss.Part) *Fortifier {
 	var m *MetadataSss
 	if len(parts) > 0 {
 		m = &MetadataSss{
 			Timestamp: parts[0].Timestamp,
 			Digest:    parts[0].Digest,
-			Parts:     parts[0].Parts,
+			Set:       parts[0].Set,
+			Parts:     sss.MaxParts(parts),
 			Threshold: parts[0].Threshold,
+			Weights:   parts[0].Weights,
+		}
+		if len(parts[0].Groups) > 0 {
+			// The parts and threshold of group sharing count the members of all groups.
+			m.Groups, m.GroupThreshold = parts[0].Groups, parts[0].GroupThreshold
+			if groups, err := sss.ParseGroups(m.Groups); err == nil {
+				m.Parts, m.Threshold = sss.GroupTotals(groups, m.GroupThreshold)
+			}
 		}
 	} else {
 		m = &MetadataSss{Parts: 2, Threshold: 2}
 	}
+	if meta != nil && meta.Sss != nil {
+		m.Kdf = meta.Sss.Kdf
+		m.Salt = meta.Sss.Salt
+	} else {
+		m.Kdf = sssKdfHkdfSha256
+	}
 	return &Fortifier{
 		meta:     &Metadata{Sss: m},
 		key:      &CipherKeyData{kind: CipherKeyKindSSS, parts: parts},
 		verbose:  verbose,
 		truncate: truncate,
+		policy:   policy,
 	}
 }
 
+// SetKeyPartLabels sets the labels written into the key parts generated for encryption.
+func (f *Fortifier) SetKeyPartLabels(l sss.Labels) {
+	f.labels = l
+}
+
+// SetKeyPartSealing asks to seal each key part generated for encryption with a passphrase of its holder.
+func (f *Fortifier) SetKeyPartSealing(seal bool) {
+	f.seal = seal
+}
+
 func (f *Fortifier) setupSssKey() (err error) {
 	f.meta.Key = CipherKeyKindSSS
 	f.meta.Timestamp = time.Now()
+	if m := f.meta.Sss; len(m.Kdf) > 0 && len(m.Salt) == 0 {
+		if err = f.policy.CheckSss(m.Parts, m.Threshold); err != nil {
+			return
+		}
+	}
+	var secret []byte
+	defer func() { utils.Wipe(secret) }()
 	if len(f.key.parts) > 0 {
-		if f.key.raw, err = sss.Combine(f.key.parts); err != nil {
+		if secret, err = sss.Combine(f.key.parts); err != nil {
 			return
 		}
 	} else {
-		f.key.raw = make([]byte, 32)
-		raw := f.key.raw
+		secret = make([]byte, 32)
 		meta := f.meta
-		if _, err = rand.Read(raw); err != nil {
+		if _, err = rand.Read(secret); err != nil {
 			return
 		}
 		var ps []sss.Part
-		if ps, err = sss.Split(raw, meta.Sss.Parts, meta.Sss.Threshold); err != nil {
+		if ps, err = sss.Split(secret, meta.Sss.Parts, meta.Sss.Threshold); err != nil {
 			return
 		}
+		if err = f.labels.Apply(ps); err != nil {
+			return
+		}
+		if f.seal {
+			if err = sss.SealParts(ps); err != nil {
+				return
+			}
+		}
 		if err = sss.AppendParts(ps, 0, 1, "fortified.key", f.truncate); err != nil {
 			return
 		}
 		defer sss.CloseAllFilesForWrite()
 		meta.Sss.Digest = ps[0].Digest
+		meta.Sss.Set = ps[0].Set
 		meta.Sss.Timestamp = ps[0].Timestamp
 	}
+	var raw []byte
+	if raw, err = f.deriveSssKey(secret); err != nil {
+		return
+	}
+	f.key.setRaw(raw)
 	return
 }
+
+// deriveSssKey turns the combined secret into the AES-256 key. A salt is generated when
+// encrypting, which is when the secret is also checked against the minimum length.
+func (f *Fortifier) deriveSssKey(secret []byte) ([]byte, error) {
+	m := f.meta.Sss
+	switch m.Kdf {
+	case "":
+		return secret, nil
+	case sssKdfHkdfSha256:
+	default:
+		return nil, fmt.Errorf("%s: unknown key derivation function: %s", sssFortifier, m.Kdf)
+	}
+	var salt []byte
+	if len(m.Salt) == 0 {
+		if err := f.policy.CheckSecretLength(len(secret) * 8); err != nil {
+			return nil, err
+		}
+		salt = make([]byte, 32)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		m.Salt = base64.URLEncoding.EncodeToString(salt)
+	} else {
+		var err error
+		if salt, err = base64.URLEncoding.DecodeString(m.Salt); err != nil {
+			return nil, fmt.Errorf("%s: invalid key derivation salt. %v", sssFortifier, err)
+		}
+	}
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, sssKdfInfo), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// checkSssKey fails when the supplied key parts do not belong to the secret share set
+// the fortified file was encrypted with, so that no combining is attempted.
+func (f *Fortifier) checkSssKey(required *MetadataSss) error {
+	supplied := f.meta.Sss
+	if supplied == nil || required == nil || supplied.Digest == required.Digest {
+		return nil
+	}
+	if len(supplied.Digest) == 0 {
+		return fmt.Errorf("%s: this file requires key parts of set %s, none supplied", sssFortifier, required.SetName())
+	}
+	return fmt.Errorf("%s: this file requires key parts of set %s, you supplied set %s",
+		sssFortifier, required.SetName(), supplied.SetName())
+}
+
+// SetName identifies the secret share set by its ID, or by its digest if the ID is absent.
+func (m *MetadataSss) SetName() string {
+	if len(m.Set) > 0 {
+		return m.Set
+	}
+	if len(m.Digest) > 16 {
+		return "digest:" + m.Digest[:16] + "…"
+	}
+	return "digest:" + m.Digest
+}
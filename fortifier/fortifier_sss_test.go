@@ -0,0 +1,193 @@
+package fortifier
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wangkang/fortify/sss"
+)
+
+func TestDeriveSssKey(t *testing.T) {
+	secret := make([]byte, 32)
+	salt := make([]byte, 32)
+	for i := range secret {
+		secret[i], salt[i] = byte(i), byte(32+i)
+	}
+	// HKDF-SHA256 of the secret with the salt and info "fortify aes256 key", computed apart.
+	expect := "d310f7bee1825fc78a5425e5286a17858b4758c29902a46153b0575cd66bdbc6"
+	m := &MetadataSss{Kdf: sssKdfHkdfSha256, Salt: base64.URLEncoding.EncodeToString(salt)}
+	f := &Fortifier{meta: &Metadata{Sss: m}}
+	key, err := f.deriveSssKey(secret)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if actual := hex.EncodeToString(key); actual != expect {
+		t.Fatalf("expect %s, actual %s", expect, actual)
+	}
+
+	// Encrypting generates a salt, and so a key of its own.
+	m.Salt = ""
+	if key, err = f.deriveSssKey(secret); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	generated, err := base64.URLEncoding.DecodeString(m.Salt)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(generated) != 32 || hex.EncodeToString(key) == expect || bytes.Equal(key, secret) {
+		t.Fatalf("expect a key derived with a new salt, actual salt %x, key %x", generated, key)
+	}
+
+	// Files without a KDF name use the secret as the key.
+	m.Kdf = ""
+	if key, err = f.deriveSssKey(secret); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(key, secret) {
+		t.Fatalf("expect %x, actual %x", secret, key)
+	}
+	m.Kdf = "pbkdf2"
+	if _, err = f.deriveSssKey(secret); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+// TestDecrypt_baseline decrypts files encrypted by the build before the key derivation and the
+// recorded fingerprints, which keep decrypting with the secret as the key.
+func TestDecrypt_baseline(t *testing.T) {
+	dir := filepath.Join("testdata", "baseline")
+	plain, err := os.ReadFile(filepath.Join(dir, "plain.txt"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, name := range []string{"sss-ctr", "sss-ofb", "sss-cfb", "rsa-ctr"} {
+		in, err := os.Open(filepath.Join(dir, name+".data"))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		layout := &FileLayout{}
+		if err = layout.ReadHeadIn(in); err != nil {
+			t.Fatalf("%s: err: %v", name, err)
+		}
+		meta := layout.Metadata()
+		var f *Fortifier
+		if meta.Key == CipherKeyKindRSA {
+			var kb []byte
+			if kb, err = os.ReadFile(filepath.Join(dir, "rsa")); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			f = NewFortifierWithRsa(false, nil, meta, kb)
+		} else {
+			if len(meta.Sss.Kdf) > 0 {
+				t.Fatalf("%s: expect no key derivation, actual %s", name, meta.Sss.Kdf)
+			}
+			var parts []sss.Part
+			keys := []string{filepath.Join(dir, name+".key1of2.json"), filepath.Join(dir, name+".key2of2.json")}
+			if parts, err = sss.CombineKeyFiles(keys); err != nil {
+				t.Fatalf("%s: err: %v", name, err)
+			}
+			f = NewFortifierWithSss(false, false, nil, meta, parts)
+		}
+		var out bytes.Buffer
+		err = NewDecrypter(meta.Mode, f).Decrypt(in, &out, layout)
+		f.Close()
+		_ = in.Close()
+		if err != nil {
+			t.Fatalf("%s: err: %v", name, err)
+		}
+		if !bytes.Equal(out.Bytes(), plain) {
+			t.Fatalf("%s: expect %q, actual %q", name, plain, out.Bytes())
+		}
+	}
+}
+
+// encryptTestFile encrypts the plain text into a new file with the fortifier, and returns its path.
+func encryptTestFile(t *testing.T, f *Fortifier, plain []byte) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fortified.data")
+	in, err := os.CreateTemp(dir, "plain")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer func() { _ = in.Close() }()
+	if _, err = in.Write(plain); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err = in.Seek(0, 0); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer func() { _ = out.Close() }()
+	enc, err := NewEncrypter(CipherModeAes256CTR, f)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err = enc.EncryptFile(in, out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return path
+}
+
+// decryptTestFile decrypts the file with the key parts.
+func decryptTestFile(t *testing.T, path string, parts []sss.Part) ([]byte, error) {
+	t.Helper()
+	in, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer func() { _ = in.Close() }()
+	layout := &FileLayout{}
+	if err = layout.ReadHeadIn(in); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	meta := layout.Metadata()
+	f := NewFortifierWithSss(false, false, nil, meta, parts)
+	defer f.Close()
+	var out bytes.Buffer
+	err = NewDecrypter(meta.Mode, f).Decrypt(in, &out, layout)
+	return out.Bytes(), err
+}
+
+func TestDecrypt_reshare(t *testing.T) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ps, err := sss.Split(secret, 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	plain := []byte("test reshare")
+	f := NewFortifierWithSss(false, false, nil, nil, ps[:2])
+	path := encryptTestFile(t, f, plain)
+	f.Close()
+
+	reshared, err := sss.Reshare(ps[1:], 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	decrypted, err := decryptTestFile(t, path, []sss.Part{reshared[0], reshared[3], reshared[4]})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(decrypted, plain) {
+		t.Fatalf("expect %q, actual %q", plain, decrypted)
+	}
+	// The parts of another secret are refused before combining.
+	others, err := sss.Split(secret[:31], 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err = decryptTestFile(t, path, others[:2]); err == nil {
+		t.Fatalf("expect error")
+	}
+}
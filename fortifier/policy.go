@@ -0,0 +1,126 @@
+package fortifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// PolicyEnv names the environment variable holding the path of the policy file.
+const PolicyEnv = "FORTIFY_POLICY"
+
+// Policy restricts the keys and cipher modes used for encryption.
+// Zero values and empty lists leave the corresponding property unrestricted.
+type Policy struct {
+	MinRsaBits          int              `json:"min_rsa_bits,omitempty"`
+	KeyKinds            []CipherKeyKind  `json:"key_kinds,omitempty"`
+	Modes               []CipherModeName `json:"modes,omitempty"`
+	MinSssParts         uint8            `json:"min_sss_parts,omitempty"`
+	MinSssThreshold     uint8            `json:"min_sss_threshold,omitempty"`
+	MinSecretLengthBits int              `json:"min_secret_length_bits,omitempty"`
+}
+
+// DefaultPolicyPath returns the policy file named by FORTIFY_POLICY, or fortify/policy.json in the
+// user configuration directory if that file exists, or an empty string.
+func DefaultPolicyPath() string {
+	if path := os.Getenv(PolicyEnv); len(path) > 0 {
+		return path
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		path := filepath.Join(dir, "fortify", "policy.json")
+		if _, err = os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// LoadPolicy reads a policy file. An empty path yields an unrestricted policy.
+func LoadPolicy(path string) (*Policy, error) {
+	p := &Policy{}
+	if len(path) == 0 {
+		return p, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("policy: %v", err)
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.DisallowUnknownFields()
+	if err = d.Decode(p); err != nil {
+		return nil, fmt.Errorf("policy: invalid policy file %s -- %v", path, err)
+	}
+	return p, nil
+}
+
+func (p *Policy) CheckKeyKind(kind CipherKeyKind) error {
+	if p == nil || len(p.KeyKinds) == 0 || slices.Contains(p.KeyKinds, kind) {
+		return nil
+	}
+	return fmt.Errorf("policy: cipher key kind %q is not allowed, allowed kinds: %v", kind, p.KeyKinds)
+}
+
+func (p *Policy) CheckMode(mode CipherModeName) error {
+	if p == nil || len(p.Modes) == 0 || slices.Contains(p.Modes, mode) {
+		return nil
+	}
+	return fmt.Errorf("policy: cipher mode %q is not allowed, allowed modes: %v", mode, p.Modes)
+}
+
+func (p *Policy) CheckRsaBits(bits int) error {
+	if p == nil || bits >= p.MinRsaBits {
+		return nil
+	}
+	return fmt.Errorf("policy: RSA key has %d bits, at least %d bits are required", bits, p.MinRsaBits)
+}
+
+func (p *Policy) CheckSss(parts, threshold uint8) error {
+	if p == nil {
+		return nil
+	}
+	var errs []error
+	if parts < p.MinSssParts {
+		errs = append(errs, fmt.Errorf("policy: secret share set has %d parts, at least %d parts are required",
+			parts, p.MinSssParts))
+	}
+	if threshold < p.MinSssThreshold {
+		errs = append(errs, fmt.Errorf("policy: secret share set has threshold %d, at least %d is required",
+			threshold, p.MinSssThreshold))
+	}
+	return errors.Join(errs...)
+}
+
+// CheckSecretLength checks the length in bits of a combined secret, which tells nothing of its entropy.
+func (p *Policy) CheckSecretLength(bits int) error {
+	if p == nil || bits >= p.MinSecretLengthBits {
+		return nil
+	}
+	return fmt.Errorf("policy: combined secret is %d bits long, at least %d bits are required", bits,
+		p.MinSecretLengthBits)
+}
+
+// Violations lists the ways the metadata of an existing fortified file violates the policy.
+func (p *Policy) Violations(meta *Metadata) (errs []error) {
+	check := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	check(p.CheckKeyKind(meta.Key))
+	check(p.CheckMode(meta.Mode))
+	if meta.Sss != nil {
+		check(p.CheckSss(meta.Sss.Parts, meta.Sss.Threshold))
+	}
+	if meta.Rsa != nil && p != nil && p.MinRsaBits > 0 {
+		if meta.Rsa.Bits == 0 {
+			check(errors.New("policy: RSA key size is not recorded in the file"))
+		} else {
+			check(p.CheckRsaBits(meta.Rsa.Bits))
+		}
+	}
+	return
+}
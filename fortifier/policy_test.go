@@ -0,0 +1,130 @@
+package fortifier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	p, err := LoadPolicy("")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if p.MinRsaBits != 0 || len(p.KeyKinds) > 0 || len(p.Modes) > 0 || p.MinSecretLengthBits != 0 {
+		t.Fatalf("expect an unrestricted policy, actual %+v", p)
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy.json")
+	content := `{"min_rsa_bits":3072,"key_kinds":["sss"],"modes":["aes256-ctr"],"min_sss_parts":3,` +
+		`"min_sss_threshold":2,"min_secret_length_bits":256}`
+	if err = os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if p, err = LoadPolicy(path); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if p.MinRsaBits != 3072 || len(p.KeyKinds) != 1 || len(p.Modes) != 1 || p.MinSssParts != 3 ||
+		p.MinSssThreshold != 2 || p.MinSecretLengthBits != 256 {
+		t.Fatalf("unexpected policy %+v", p)
+	}
+
+	// Unknown fields are refused rather than silently ignored.
+	for name, content := range map[string]string{
+		"unknown field": `{"min_secret_bits":256}`,
+		"not JSON":      `min_rsa_bits: 3072`,
+	} {
+		if err = os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if _, err = LoadPolicy(path); err == nil {
+			t.Fatalf("%s: expect error", name)
+		}
+	}
+	if _, err = LoadPolicy(filepath.Join(dir, "none.json")); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestPolicy_check(t *testing.T) {
+	p := &Policy{
+		MinRsaBits:          3072,
+		KeyKinds:            []CipherKeyKind{CipherKeyKindSSS},
+		Modes:               []CipherModeName{CipherModeAes256CTR},
+		MinSssParts:         3,
+		MinSssThreshold:     2,
+		MinSecretLengthBits: 256,
+	}
+	for name, c := range map[string]struct {
+		accepted, rejected error
+	}{
+		"key kind":      {p.CheckKeyKind(CipherKeyKindSSS), p.CheckKeyKind(CipherKeyKindRSA)},
+		"mode":          {p.CheckMode(CipherModeAes256CTR), p.CheckMode(CipherModeAes256CFB)},
+		"rsa bits":      {p.CheckRsaBits(4096), p.CheckRsaBits(2048)},
+		"sss parts":     {p.CheckSss(3, 2), p.CheckSss(2, 2)},
+		"sss threshold": {p.CheckSss(5, 3), p.CheckSss(5, 1)},
+		"secret length": {p.CheckSecretLength(256), p.CheckSecretLength(128)},
+	} {
+		if c.accepted != nil {
+			t.Fatalf("%s: err: %v", name, c.accepted)
+		}
+		if c.rejected == nil {
+			t.Fatalf("%s: expect error", name)
+		}
+	}
+
+	// Zero values, empty lists and no policy at all leave everything unrestricted.
+	for _, unrestricted := range []*Policy{{}, nil} {
+		for name, err := range map[string]error{
+			"key kind":      unrestricted.CheckKeyKind(CipherKeyKindRSA),
+			"mode":          unrestricted.CheckMode(CipherModeAes256OFB),
+			"rsa bits":      unrestricted.CheckRsaBits(1024),
+			"sss":           unrestricted.CheckSss(2, 2),
+			"secret length": unrestricted.CheckSecretLength(8),
+		} {
+			if err != nil {
+				t.Fatalf("%s: err: %v", name, err)
+			}
+		}
+	}
+}
+
+func TestPolicy_Violations(t *testing.T) {
+	p := &Policy{MinRsaBits: 3072, KeyKinds: []CipherKeyKind{CipherKeyKindSSS}, Modes: []CipherModeName{CipherModeAes256CTR},
+		MinSssParts: 3}
+	meta := &Metadata{Key: CipherKeyKindSSS, Mode: CipherModeAes256CTR, Sss: &MetadataSss{Parts: 3, Threshold: 2}}
+	if errs := p.Violations(meta); len(errs) > 0 {
+		t.Fatalf("expect no violations, actual %v", errs)
+	}
+	meta = &Metadata{Key: CipherKeyKindSSS, Mode: CipherModeAes256OFB, Sss: &MetadataSss{Parts: 2, Threshold: 2}}
+	if errs := p.Violations(meta); len(errs) != 2 {
+		t.Fatalf("expect violations of the mode and parts, actual %v", errs)
+	}
+	// Files of old builds do not record the size of their RSA key.
+	meta = &Metadata{Key: CipherKeyKindRSA, Mode: CipherModeAes256CTR, Rsa: &MetadataRsa{}}
+	if errs := p.Violations(meta); len(errs) != 2 {
+		t.Fatalf("expect violations of the key kind and size, actual %v", errs)
+	}
+	meta.Rsa.Bits = 4096
+	if errs := (&Policy{MinRsaBits: 3072}).Violations(meta); len(errs) > 0 {
+		t.Fatalf("expect no violations, actual %v", errs)
+	}
+}
+
+func TestPolicy_encrypt(t *testing.T) {
+	p := &Policy{Modes: []CipherModeName{CipherModeAes256CTR}, MinSecretLengthBits: 256}
+	f := &Fortifier{meta: &Metadata{Sss: &MetadataSss{Kdf: sssKdfHkdfSha256}}, key: &CipherKeyData{}, policy: p}
+	if _, err := NewEncrypter(CipherModeAes256OFB, f); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err := NewEncrypter(CipherModeAes256CTR, f); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// The length of the secret is checked on encryption, whatever its entropy.
+	if _, err := f.deriveSssKey(make([]byte, 16)); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err := f.deriveSssKey(make([]byte, 32)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
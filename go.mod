@@ -7,6 +7,7 @@ require (
 	golang.org/x/crypto v0.25.0
 	golang.org/x/sys v0.23.0
 	golang.org/x/term v0.22.0
+	rsc.io/qr v0.2.0
 )
 
 require (
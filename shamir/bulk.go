@@ -0,0 +1,159 @@
+package shamir
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"runtime"
+	"sync"
+)
+
+// The bulk routines below handle whole secrets at once instead of one byte
+// at a time: eight field elements are packed into a uint64 and multiplied by
+// a public scalar together, without secret-indexed tables, so they stay
+// constant-time with respect to the secret. Secrets larger than bulkChunkSize
+// are processed in parallel.
+
+const (
+	bulkChunkSize = 64 * 1024 // a multiple of 8
+	ones          = 0x0101010101010101
+)
+
+// scalar holds the products of a field element with 2^0, ..., 2^7, by which
+// the bit planes of packed field elements are multiplied.
+type scalar [8]uint64
+
+func newScalar(a uint8) (s scalar) {
+	for i := range s {
+		s[i] = uint64(a)
+		a = mult(a, 2)
+	}
+	return s
+}
+
+// mulVector multiplies each of the 8 field elements packed in v by s.
+func mulVector(v uint64, s *scalar) uint64 {
+	// Every byte of a bit plane is 0 or 1, so multiplying it by a byte
+	// cannot carry into the neighbouring bytes.
+	return (v&ones)*s[0] ^ (v>>1&ones)*s[1] ^ (v>>2&ones)*s[2] ^ (v>>3&ones)*s[3] ^
+		(v>>4&ones)*s[4] ^ (v>>5&ones)*s[5] ^ (v>>6&ones)*s[6] ^ (v>>7&ones)*s[7]
+}
+
+// loadVector packs up to 8 bytes of b from off, padding with zeros.
+func loadVector(b []byte, off int) uint64 {
+	if off+8 <= len(b) {
+		return binary.LittleEndian.Uint64(b[off:])
+	}
+	var tail [8]byte
+	copy(tail[:], b[off:])
+	return binary.LittleEndian.Uint64(tail[:])
+}
+
+// storeVector unpacks up to 8 bytes into b from off.
+func storeVector(b []byte, off int, v uint64) {
+	if off+8 <= len(b) {
+		binary.LittleEndian.PutUint64(b[off:], v)
+		return
+	}
+	var tail [8]byte
+	binary.LittleEndian.PutUint64(tail[:], v)
+	copy(b[off:], tail[:])
+}
+
+// parallelChunks calls fn on the chunks of [0, n), in parallel if there are
+// several, and returns the first error.
+func parallelChunks(n int, fn func(lo, hi int) error) error {
+	chunks := (n + bulkChunkSize - 1) / bulkChunkSize
+	workers := min(runtime.NumCPU(), chunks)
+	if workers <= 1 {
+		return fn(0, n)
+	}
+	var wg sync.WaitGroup
+	var once sync.Once
+	var first error
+	next := make(chan int, chunks)
+	for c := 0; c < chunks; c++ {
+		next <- c
+	}
+	close(next)
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for c := range next {
+				if err := fn(c*bulkChunkSize, min((c+1)*bulkChunkSize, n)); err != nil {
+					once.Do(func() { first = err })
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return first
+}
+
+// splitBulk fills out[i][:len(secret)] with the values at xs[i] of random
+// polynomials of the given degree, one per byte of the secret, whose
+// intercepts are the bytes of the secret.
+func splitBulk(secret []byte, out [][]byte, xs []uint8, degree int) error {
+	return parallelChunks(len(secret), func(lo, hi int) error {
+		size := hi - lo
+		coefficients := make([]byte, degree*size)
+		defer clear(coefficients)
+		if _, err := rand.Read(coefficients); err != nil {
+			return err
+		}
+		// The coefficients of degree d+1 of the bytes are planes[d].
+		planes := make([][]byte, degree)
+		for d := range planes {
+			planes[d] = coefficients[d*size : (d+1)*size]
+		}
+		chunk := secret[lo:hi]
+		for i, x := range xs {
+			share := out[i][lo:hi]
+			s := newScalar(x)
+			for off := 0; off < size; off += 8 {
+				// Horner's method from the highest degree down to the intercept
+				y := loadVector(planes[degree-1], off)
+				for d := degree - 2; d >= 0; d-- {
+					y = mulVector(y, &s) ^ loadVector(planes[d], off)
+				}
+				storeVector(share, off, mulVector(y, &s)^loadVector(chunk, off))
+			}
+		}
+		return nil
+	})
+}
+
+// interpolateBulk fills out with the sums of the first len(out) bytes of the
+// parts weighted by the Lagrange basis at some x, given as weights.
+func interpolateBulk(parts [][]byte, weights []uint8, out []byte) {
+	scalars := make([]scalar, len(weights))
+	for i, w := range weights {
+		scalars[i] = newScalar(w)
+	}
+	_ = parallelChunks(len(out), func(lo, hi int) error {
+		for off := lo; off < hi; off += 8 {
+			var y uint64
+			for i, part := range parts {
+				y ^= mulVector(loadVector(part[:hi], off), &scalars[i])
+			}
+			storeVector(out[:hi], off, y)
+		}
+		return nil
+	})
+}
+
+// lagrangeWeights returns the Lagrange basis of the x coordinates at x, by
+// which the values at the coordinates are weighted to interpolate at x.
+func lagrangeWeights(xs []uint8, x uint8) []uint8 {
+	weights := make([]uint8, len(xs))
+	for i := range xs {
+		var basis uint8 = 1
+		for j := range xs {
+			if i != j {
+				basis = mult(basis, div(add(x, xs[j]), add(xs[i], xs[j])))
+			}
+		}
+		weights[i] = basis
+	}
+	return weights
+}
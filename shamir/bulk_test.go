@@ -0,0 +1,136 @@
+package shamir
+
+import (
+	"bytes"
+	"crypto/rand"
+	mathrand "math/rand"
+	"testing"
+)
+
+// splitBytewise splits the secret one byte at a time, as Split used to, for
+// reference and comparison.
+func splitBytewise(secret []byte, parts, threshold int) ([][]byte, error) {
+	xCoordinates := mathrand.Perm(255)
+	out := make([][]byte, parts)
+	for idx := range out {
+		out[idx] = make([]byte, len(secret)+1)
+		out[idx][len(secret)] = uint8(xCoordinates[idx]) + 1
+	}
+	for idx, val := range secret {
+		p, err := makePolynomial(val, uint8(threshold-1))
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < parts; i++ {
+			out[i][idx] = p.evaluate(uint8(xCoordinates[i]) + 1)
+		}
+	}
+	return out, nil
+}
+
+// combineBytewise combines the parts one byte at a time, as Combine used to,
+// for reference and comparison.
+func combineBytewise(parts [][]byte) []byte {
+	size := len(parts[0]) - 1
+	secret := make([]byte, size)
+	x_samples := make([]uint8, len(parts))
+	y_samples := make([]uint8, len(parts))
+	for i, part := range parts {
+		x_samples[i] = part[size]
+	}
+	for idx := range secret {
+		for i, part := range parts {
+			y_samples[i] = part[idx]
+		}
+		secret[idx] = interpolatePolynomial(x_samples, y_samples, 0)
+	}
+	return secret
+}
+
+func TestMulVector(t *testing.T) {
+	var v [8]byte
+	for a := 0; a < 256; a++ {
+		s := newScalar(uint8(a))
+		for b := 0; b < 256; b += 8 {
+			for i := range v {
+				v[i] = uint8(b + i)
+			}
+			var out [8]byte
+			storeVector(out[:], 0, mulVector(loadVector(v[:], 0), &s))
+			for i := range v {
+				if expect := mult(v[i], uint8(a)); out[i] != expect {
+					t.Fatalf("mulVector %d * %d = %d, expect %d", v[i], a, out[i], expect)
+				}
+			}
+		}
+	}
+}
+
+func TestSplit_bulk(t *testing.T) {
+	for _, size := range []int{1, 7, 8, 9, 1000, 3*bulkChunkSize + 5} {
+		secret := make([]byte, size)
+		if _, err := rand.Read(secret); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		out, err := Split(secret, 5, 3)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if recomb := combineBytewise([][]byte{out[4], out[0], out[2]}); !bytes.Equal(recomb, secret) {
+			t.Fatalf("size %d: bytewise combine of bulk split differs", size)
+		}
+		if out, err = splitBytewise(secret, 5, 3); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		recomb, err := Combine([][]byte{out[1], out[3], out[2]})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !bytes.Equal(recomb, secret) {
+			t.Fatalf("size %d: bulk combine of bytewise split differs", size)
+		}
+	}
+}
+
+func benchmarkSecret(b *testing.B) []byte {
+	secret := make([]byte, 512*1024)
+	if _, err := rand.Read(secret); err != nil {
+		b.Fatalf("err: %v", err)
+	}
+	b.SetBytes(int64(len(secret)))
+	return secret
+}
+
+func BenchmarkSplit(b *testing.B) {
+	secret := benchmarkSecret(b)
+	for i := 0; i < b.N; i++ {
+		if _, err := Split(secret, 5, 3); err != nil {
+			b.Fatalf("err: %v", err)
+		}
+	}
+}
+
+func BenchmarkSplit_bytewise(b *testing.B) {
+	secret := benchmarkSecret(b)
+	for i := 0; i < b.N; i++ {
+		if _, err := splitBytewise(secret, 5, 3); err != nil {
+			b.Fatalf("err: %v", err)
+		}
+	}
+}
+
+func BenchmarkCombine(b *testing.B) {
+	out, _ := Split(benchmarkSecret(b), 5, 3)
+	for i := 0; i < b.N; i++ {
+		if _, err := Combine(out[:3]); err != nil {
+			b.Fatalf("err: %v", err)
+		}
+	}
+}
+
+func BenchmarkCombine_bytewise(b *testing.B) {
+	out, _ := Split(benchmarkSecret(b), 5, 3)
+	for i := 0; i < b.N; i++ {
+		combineBytewise(out[:3])
+	}
+}
@@ -0,0 +1,218 @@
+package shamir
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// FeldmanElementSize is the byte size of a group element or an
+	// exponent when encoded in a verifiable share or a commitment.
+	FeldmanElementSize = 256
+
+	// FeldmanMaxSecretSize is the largest secret SplitVerifiable accepts.
+	FeldmanMaxSecretSize = FeldmanElementSize - 2
+)
+
+// feldmanP is the 2048-bit MODP safe prime of RFC 3526 (group 14). The
+// generator 2 spans its subgroup of prime order q = (p-1)/2, in which the
+// verifiable shares are committed to.
+var (
+	feldmanP, _ = new(big.Int).SetString(
+		"FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD1"+
+			"29024E088A67CC74020BBEA63B139B22514A08798E3404DD"+
+			"EF9519B3CD3A431B302B0A6DF25F14374FE1356D6D51C245"+
+			"E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED"+
+			"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3D"+
+			"C2007CB8A163BF0598DA48361C55D39A69163FA8FD24CF5F"+
+			"83655D23DCA3AD961C62F356208552BB9ED529077096966D"+
+			"670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B"+
+			"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9"+
+			"DE2BCBF6955817183995497CEA956AE515D2261898FA0510"+
+			"15728E5A8AACAA68FFFFFFFFFFFFFFFF", 16)
+	feldmanQ = new(big.Int).Rsh(feldmanP, 1)
+	feldmanG = big.NewInt(2)
+)
+
+// SplitVerifiable splits the secret like Split, but over the integers
+// modulo the prime group order q instead of GF(2^8), and additionally
+// returns Feldman commitments to the coefficients of the polynomial. Each
+// share can be checked against the commitments with VerifyShare without
+// learning anything about the secret. The secret must not be longer than
+// FeldmanMaxSecretSize. The representation of each share is {y, x}, where y
+// takes FeldmanElementSize bytes and x is the one byte tag as in Split.
+func SplitVerifiable(secret []byte, parts, threshold int) (shares, commitments [][]byte, err error) {
+	// Sanity check the input
+	if parts < threshold {
+		return nil, nil, fmt.Errorf("parts cannot be less than threshold")
+	}
+	if parts > 255 {
+		return nil, nil, fmt.Errorf("parts cannot exceed 255")
+	}
+	if threshold < 2 {
+		return nil, nil, fmt.Errorf("threshold must be at least 2")
+	}
+	if len(secret) == 0 {
+		return nil, nil, fmt.Errorf("cannot split an empty secret")
+	}
+	if len(secret) > FeldmanMaxSecretSize {
+		return nil, nil, fmt.Errorf("secret cannot exceed %d bytes", FeldmanMaxSecretSize)
+	}
+
+	// Prefix the secret with a one so that its leading zero bytes survive
+	// the conversion into an integer.
+	coefficients := make([]*big.Int, threshold)
+	coefficients[0] = new(big.Int).SetBytes(append([]byte{1}, secret...))
+	for i := 1; i < threshold; i++ {
+		if coefficients[i], err = rand.Int(rand.Reader, feldmanQ); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate polynomial: %w", err)
+		}
+	}
+
+	commitments = make([][]byte, threshold)
+	for i, a := range coefficients {
+		commitments[i] = new(big.Int).Exp(feldmanG, a, feldmanP).FillBytes(make([]byte, FeldmanElementSize))
+	}
+
+	// The x coordinates of verifiable shares are simply 1..parts.
+	shares = make([][]byte, parts)
+	for i := range shares {
+		x := big.NewInt(int64(i + 1))
+		y := new(big.Int)
+		for j := threshold - 1; j >= 0; j-- {
+			y.Mul(y, x)
+			y.Add(y, coefficients[j])
+			y.Mod(y, feldmanQ)
+		}
+		shares[i] = make([]byte, FeldmanElementSize+1)
+		y.FillBytes(shares[i][:FeldmanElementSize])
+		shares[i][FeldmanElementSize] = uint8(i + 1)
+	}
+	return shares, commitments, nil
+}
+
+// VerifyShare checks a share made by SplitVerifiable against the
+// commitments published with it, by testing g^y = C0 * C1^x * C2^x^2 ...
+func VerifyShare(share []byte, commitments [][]byte) error {
+	x, y, err := parseVerifiableShare(share)
+	if err != nil {
+		return err
+	}
+	if len(commitments) < 2 {
+		return fmt.Errorf("less than two commitments cannot verify a share")
+	}
+	expect := big.NewInt(1)
+	power := big.NewInt(1)
+	for i, commitment := range commitments {
+		if len(commitment) != FeldmanElementSize {
+			return fmt.Errorf("commitment %d must be %d bytes", i, FeldmanElementSize)
+		}
+		c := new(big.Int).SetBytes(commitment)
+		if c.Cmp(big.NewInt(1)) < 0 || c.Cmp(feldmanP) >= 0 ||
+			new(big.Int).Exp(c, feldmanQ, feldmanP).Cmp(big.NewInt(1)) != 0 {
+			return fmt.Errorf("commitment %d is not an element of the group", i)
+		}
+		expect.Mul(expect, new(big.Int).Exp(c, power, feldmanP))
+		expect.Mod(expect, feldmanP)
+		power.Mul(power, x)
+		power.Mod(power, feldmanQ)
+	}
+	if new(big.Int).Exp(feldmanG, y, feldmanP).Cmp(expect) != 0 {
+		return fmt.Errorf("share %d does not match the commitments", x)
+	}
+	return nil
+}
+
+// CombineVerifiable is used to reverse a SplitVerifiable and reconstruct a
+// secret once a `threshold` number of parts are available.
+func CombineVerifiable(parts [][]byte) ([]byte, error) {
+	xs, ys, err := parseVerifiableShares(parts)
+	if err != nil {
+		return nil, err
+	}
+	s := interpolateModQ(xs, ys, new(big.Int)).Bytes()
+	if len(s) < 2 || s[0] != 1 {
+		return nil, fmt.Errorf("reconstructed secret is malformed")
+	}
+	return s[1:], nil
+}
+
+// ExtendVerifiable issues an additional share at the x coordinate x, which
+// verifies against the same commitments as the given parts do.
+func ExtendVerifiable(parts [][]byte, x uint8) ([]byte, error) {
+	if x == 0 {
+		return nil, fmt.Errorf("x coordinate of a share cannot be zero")
+	}
+	xs, ys, err := parseVerifiableShares(parts)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range xs {
+		if i.Int64() == int64(x) {
+			return nil, fmt.Errorf("duplicate part or x coordinate detected")
+		}
+	}
+	out := make([]byte, FeldmanElementSize+1)
+	interpolateModQ(xs, ys, big.NewInt(int64(x))).FillBytes(out[:FeldmanElementSize])
+	out[FeldmanElementSize] = x
+	return out, nil
+}
+
+func parseVerifiableShare(share []byte) (x, y *big.Int, err error) {
+	if len(share) != FeldmanElementSize+1 {
+		return nil, nil, fmt.Errorf("verifiable parts must be %d bytes", FeldmanElementSize+1)
+	}
+	if share[FeldmanElementSize] == 0 {
+		return nil, nil, fmt.Errorf("x coordinate of a share cannot be zero")
+	}
+	x = big.NewInt(int64(share[FeldmanElementSize]))
+	y = new(big.Int).SetBytes(share[:FeldmanElementSize])
+	if y.Cmp(feldmanQ) >= 0 {
+		return nil, nil, fmt.Errorf("share %d is out of range", x)
+	}
+	return x, y, nil
+}
+
+func parseVerifiableShares(parts [][]byte) (xs, ys []*big.Int, err error) {
+	if len(parts) < 2 {
+		return nil, nil, fmt.Errorf("less than two parts cannot be used to reconstruct the secret")
+	}
+	checkMap := map[int64]bool{}
+	xs = make([]*big.Int, len(parts))
+	ys = make([]*big.Int, len(parts))
+	for i, part := range parts {
+		if xs[i], ys[i], err = parseVerifiableShare(part); err != nil {
+			return nil, nil, err
+		}
+		if checkMap[xs[i].Int64()] {
+			return nil, nil, fmt.Errorf("duplicate part detected")
+		}
+		checkMap[xs[i].Int64()] = true
+	}
+	return xs, ys, nil
+}
+
+// interpolateModQ evaluates the polynomial through the given samples at x
+// by lagrange interpolation modulo q.
+func interpolateModQ(xs, ys []*big.Int, x *big.Int) *big.Int {
+	result := new(big.Int)
+	for i := range xs {
+		num := big.NewInt(1)
+		den := big.NewInt(1)
+		for j := range xs {
+			if i == j {
+				continue
+			}
+			num.Mul(num, new(big.Int).Sub(x, xs[j]))
+			num.Mod(num, feldmanQ)
+			den.Mul(den, new(big.Int).Sub(xs[i], xs[j]))
+			den.Mod(den, feldmanQ)
+		}
+		term := num.Mul(num, den.ModInverse(den, feldmanQ))
+		term.Mul(term, ys[i])
+		result.Add(result, term)
+		result.Mod(result, feldmanQ)
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package shamir
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestFeldmanGroup(t *testing.T) {
+	if !feldmanP.ProbablyPrime(20) || !feldmanQ.ProbablyPrime(20) {
+		t.Fatalf("p and q must be prime")
+	}
+	if new(big.Int).Exp(feldmanG, feldmanQ, feldmanP).Cmp(big.NewInt(1)) != 0 {
+		t.Fatalf("g must be of order q")
+	}
+}
+
+func TestSplitVerifiable(t *testing.T) {
+	secret := []byte("\x00\x00test")
+
+	out, commitments, err := SplitVerifiable(secret, 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(out) != 5 || len(commitments) != 3 {
+		t.Fatalf("bad: %v %v", out, commitments)
+	}
+	for _, share := range out {
+		if err := VerifyShare(share, commitments); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	recomb, err := CombineVerifiable([][]byte{out[4], out[1], out[2]})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v %v", recomb, secret)
+	}
+
+	extra, err := ExtendVerifiable(out[:3], 6)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := VerifyShare(extra, commitments); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	recomb, err = CombineVerifiable([][]byte{out[3], extra, out[4]})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v %v", recomb, secret)
+	}
+}
+
+func TestVerifyShare_invalid(t *testing.T) {
+	out, commitments, err := SplitVerifiable([]byte("test"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	bad := bytes.Clone(out[0])
+	bad[FeldmanElementSize-1] ^= 1
+	if err := VerifyShare(bad, commitments); err == nil {
+		t.Fatalf("should err")
+	}
+
+	other, _, err := SplitVerifiable([]byte("test"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := VerifyShare(other[0], commitments); err == nil {
+		t.Fatalf("should err")
+	}
+
+	if _, _, err := SplitVerifiable(make([]byte, FeldmanMaxSecretSize+1), 3, 2); err == nil {
+		t.Fatalf("should err")
+	}
+}
@@ -0,0 +1,118 @@
+package shamir
+
+import (
+	"fmt"
+	mathrand "math/rand"
+)
+
+// SplitIDA disperses data into `parts` shares, any `threshold` of which
+// reconstruct it, with Rabin's information dispersal: the data is cut into
+// `threshold` planes, which are the coefficients of polynomials evaluated
+// at the x coordinate of every share. Each share is thus about
+// len(data)/threshold bytes long, with the x coordinate attached as in Split.
+// Unlike Split, the shares hide nothing of the data.
+func SplitIDA(data []byte, parts, threshold int) ([][]byte, error) {
+	if parts < threshold {
+		return nil, fmt.Errorf("parts cannot be less than threshold")
+	}
+	if parts > 255 {
+		return nil, fmt.Errorf("parts cannot exceed 255")
+	}
+	if threshold < 1 {
+		return nil, fmt.Errorf("threshold must be at least 1")
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("cannot split empty data")
+	}
+	size := (len(data) + threshold - 1) / threshold
+	planes := make([][]byte, threshold)
+	for k := range planes {
+		planes[k] = make([]byte, size)
+		if k*size < len(data) {
+			copy(planes[k], data[k*size:min((k+1)*size, len(data))])
+		}
+	}
+	xCoordinates := mathrand.Perm(255)
+	out := make([][]byte, parts)
+	for i := range out {
+		x := uint8(xCoordinates[i]) + 1
+		// The weights of the planes are the powers of x.
+		weights := make([]uint8, threshold)
+		var power uint8 = 1
+		for k := range weights {
+			weights[k] = power
+			power = mult(power, x)
+		}
+		out[i] = make([]byte, size+1)
+		out[i][size] = x
+		interpolateBulk(planes, weights, out[i][:size])
+	}
+	return out, nil
+}
+
+// CombineIDA reverses SplitIDA from `threshold` shares, and returns the data
+// padded with zeros to a multiple of threshold bytes.
+func CombineIDA(parts [][]byte, threshold int) ([]byte, error) {
+	if threshold < 1 || len(parts) < threshold {
+		return nil, fmt.Errorf("need %d parts to reconstruct the data, got %d", max(threshold, 1), len(parts))
+	}
+	parts = parts[:threshold]
+	partLen := len(parts[0])
+	if partLen < 2 {
+		return nil, fmt.Errorf("parts must be at least two bytes")
+	}
+	xs := make([]uint8, threshold)
+	checkMap := map[byte]bool{}
+	for i, part := range parts {
+		if len(part) != partLen {
+			return nil, fmt.Errorf("all parts must be the same length")
+		}
+		x := part[partLen-1]
+		if checkMap[x] {
+			return nil, fmt.Errorf("duplicate part detected")
+		}
+		checkMap[x] = true
+		xs[i] = x
+	}
+	// Plane k is weighted by the coefficients of degree k of the Lagrange
+	// basis polynomials, the rows of the inverse of the Vandermonde matrix.
+	basis := lagrangePolynomials(xs)
+	size := partLen - 1
+	data := make([]byte, threshold*size)
+	weights := make([]uint8, threshold)
+	for k := 0; k < threshold; k++ {
+		for i := range weights {
+			weights[i] = basis[i][k]
+		}
+		interpolateBulk(parts, weights, data[k*size:(k+1)*size])
+	}
+	return data, nil
+}
+
+// lagrangePolynomials returns the coefficients of the Lagrange basis
+// polynomials of the x coordinates, lowest degree first.
+func lagrangePolynomials(xs []uint8) [][]uint8 {
+	out := make([][]uint8, len(xs))
+	for i := range xs {
+		// Multiply out the product of (X + x_j) over j != i, and its value at x_i
+		coefficients := []uint8{1}
+		var denom uint8 = 1
+		for j := range xs {
+			if i == j {
+				continue
+			}
+			next := make([]uint8, len(coefficients)+1)
+			for d, c := range coefficients {
+				next[d+1] = add(next[d+1], c)
+				next[d] = add(next[d], mult(c, xs[j]))
+			}
+			coefficients = next
+			denom = mult(denom, add(xs[i], xs[j]))
+		}
+		for d := range coefficients {
+			coefficients[d] = div(coefficients[d], denom)
+		}
+		out[i] = coefficients
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package shamir
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestSplitIDA_invalid(t *testing.T) {
+	data := []byte("test")
+
+	if _, err := SplitIDA(data, 0, 0); err == nil {
+		t.Fatalf("expect error")
+	}
+
+	if _, err := SplitIDA(data, 2, 3); err == nil {
+		t.Fatalf("expect error")
+	}
+
+	if _, err := SplitIDA(data, 1000, 3); err == nil {
+		t.Fatalf("expect error")
+	}
+
+	if _, err := SplitIDA(nil, 3, 2); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestSplitIDA(t *testing.T) {
+	for _, size := range []int{1, 2, 7, 100, bulkChunkSize*2 + 3} {
+		data := make([]byte, size)
+		if _, err := rand.Read(data); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		for _, threshold := range []int{1, 2, 3, 5} {
+			out, err := SplitIDA(data, 5, threshold)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if expect := (size+threshold-1)/threshold + 1; len(out[0]) != expect {
+				t.Fatalf("share size %d, expect %d", len(out[0]), expect)
+			}
+			// Any threshold of the shares in any order reconstruct the data.
+			for start := 0; start+threshold <= 5; start++ {
+				subset := make([][]byte, 0, threshold)
+				for i := threshold - 1; i >= 0; i-- {
+					subset = append(subset, out[start+i])
+				}
+				recomb, err := CombineIDA(subset, threshold)
+				if err != nil {
+					t.Fatalf("err: %v", err)
+				}
+				if !bytes.Equal(recomb[:size], data) || len(bytes.Trim(recomb[size:], "\x00")) > 0 {
+					t.Fatalf("size %d threshold %d: bad reconstruction", size, threshold)
+				}
+			}
+		}
+	}
+}
+
+func TestCombineIDA_invalid(t *testing.T) {
+	out, err := SplitIDA([]byte("test data"), 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if _, err := CombineIDA(out[:2], 3); err == nil {
+		t.Fatalf("should err")
+	}
+
+	if _, err := CombineIDA([][]byte{out[0], out[0], out[1]}, 3); err == nil {
+		t.Fatalf("should err")
+	}
+
+	if _, err := CombineIDA([][]byte{out[0], out[1][:2], out[2]}, 3); err == nil {
+		t.Fatalf("should err")
+	}
+}
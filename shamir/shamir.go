@@ -96,7 +96,8 @@ func div(a, b uint8) uint8 {
 	return uint8(ret)
 }
 
-// inverse calculates the inverse of a number in GF(2^8)
+// inverse calculates the inverse of a number in GF(2^8) as a^254, by a fixed
+// chain of constant-time multiplications
 func inverse(a uint8) uint8 {
 	b := mult(a, a)
 	c := mult(a, b)
@@ -112,7 +113,9 @@ func inverse(a uint8) uint8 {
 	return mult(b, b)
 }
 
-// mult multiplies two numbers in GF(2^8)
+// mult multiplies two numbers in GF(2^8) modulo x^8 + x^4 + x^3 + x + 1.
+// It shifts and adds under masks instead of looking up log/exp tables, so
+// neither branches nor memory accesses depend on the secret operands.
 func mult(a, b uint8) (out uint8) {
 	var r uint8 = 0
 	var i uint8 = 8
@@ -170,21 +173,14 @@ func Split(secret []byte, parts, threshold int) ([][]byte, error) {
 	// Construct a random polynomial for each byte of the secret.
 	// Because we are using a field of size 256, we can only represent
 	// a single byte as the intercept of the polynomial, so we must
-	// use a new polynomial for each byte.
-	for idx, val := range secret {
-		p, err := makePolynomial(val, uint8(threshold-1))
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate polynomial: %w", err)
-		}
-
-		// Generate a `parts` number of (x,y) pairs
-		// We cheat by encoding the x value once as the final index,
-		// so that it only needs to be stored once.
-		for i := 0; i < parts; i++ {
-			x := uint8(xCoordinates[i]) + 1
-			y := p.evaluate(x)
-			out[i][idx] = y
-		}
+	// use a new polynomial for each byte. The polynomials of all bytes
+	// are drawn and evaluated in bulk.
+	xs := make([]uint8, parts)
+	for i := range xs {
+		xs[i] = uint8(xCoordinates[i]) + 1
+	}
+	if err := splitBulk(secret, out, xs, threshold-1); err != nil {
+		return nil, fmt.Errorf("failed to generate polynomial: %w", err)
 	}
 
 	// Return the encoded secrets
@@ -210,15 +206,8 @@ func Combine(parts [][]byte) ([]byte, error) {
 		}
 	}
 
-	// Create a buffer to store the reconstructed secret
-	secret := make([]byte, firstPartLen-1)
-
-	// Buffer to store the samples
+	// Ensure no x_sample values are the same, otherwise div() can be unhappy
 	x_samples := make([]uint8, len(parts))
-	y_samples := make([]uint8, len(parts))
-
-	// Set the x value for each sample and ensure no x_sample values are the same,
-	// otherwise div() can be unhappy
 	checkMap := map[byte]bool{}
 	for i, part := range parts {
 		samp := part[firstPartLen-1]
@@ -229,18 +218,51 @@ func Combine(parts [][]byte) ([]byte, error) {
 		x_samples[i] = samp
 	}
 
-	// Reconstruct each byte
-	for idx := range secret {
-		// Set the y value for each sample
-		for i, part := range parts {
-			y_samples[i] = part[idx]
-		}
+	// Interpolate the polynomials of all bytes at 0 to get the intercepts
+	secret := make([]byte, firstPartLen-1)
+	interpolateBulk(parts, lagrangeWeights(x_samples, 0), secret)
+	return secret, nil
+}
 
-		// Interpolate the polynomial and compute the value at 0
-		val := interpolatePolynomial(x_samples, y_samples, 0)
+// Extend is used to issue an additional share of the secret that the given
+// parts were split from, by evaluating the polynomial they interpolate at the
+// x coordinate x. At least a `threshold` number of parts must be provided, and
+// x must be neither zero nor the x coordinate of any given part.
+func Extend(parts [][]byte, x uint8) ([]byte, error) {
+	// Verify enough parts provided
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("less than two parts cannot be used to extend the shares")
+	}
+	if x == 0 {
+		return nil, fmt.Errorf("x coordinate of a share cannot be zero")
+	}
 
-		// Evaluate the 0th value to get the intercept
-		secret[idx] = val
+	// Verify the parts are all the same length
+	firstPartLen := len(parts[0])
+	if firstPartLen < 2 {
+		return nil, fmt.Errorf("parts must be at least two bytes")
 	}
-	return secret, nil
+	for i := 1; i < len(parts); i++ {
+		if len(parts[i]) != firstPartLen {
+			return nil, fmt.Errorf("all parts must be the same length")
+		}
+	}
+
+	// Collect the x values and ensure they are unique and differ from x
+	x_samples := make([]uint8, len(parts))
+	checkMap := map[byte]bool{x: true}
+	for i, part := range parts {
+		samp := part[firstPartLen-1]
+		if exists := checkMap[samp]; exists {
+			return nil, fmt.Errorf("duplicate part or x coordinate detected")
+		}
+		checkMap[samp] = true
+		x_samples[i] = samp
+	}
+
+	// Evaluate the polynomials of all bytes at x
+	out := make([]byte, firstPartLen)
+	out[firstPartLen-1] = x
+	interpolateBulk(parts, lagrangeWeights(x_samples, x), out[:firstPartLen-1])
+	return out, nil
 }
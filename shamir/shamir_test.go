@@ -156,6 +156,66 @@ func TestField_Divide(t *testing.T) {
 	}
 }
 
+// fieldTables builds the log/exp tables of GF(2^8) with the generator 3, as
+// a reference for the constant-time arithmetic.
+func fieldTables() (logTable [256]uint8, expTable [255]uint8) {
+	var x uint8 = 1
+	for i := 0; i < 255; i++ {
+		expTable[i] = x
+		logTable[x] = uint8(i)
+		// x *= 3, reducing by x^8 + x^4 + x^3 + x + 1
+		hi := x & 0x80
+		x ^= x << 1
+		if hi != 0 {
+			x ^= 0x1B
+		}
+	}
+	return
+}
+
+func TestField_Mult_tables(t *testing.T) {
+	logTable, expTable := fieldTables()
+	for a := 0; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			var expect uint8
+			if a != 0 && b != 0 {
+				expect = expTable[(int(logTable[a])+int(logTable[b]))%255]
+			}
+			if out := mult(uint8(a), uint8(b)); out != expect {
+				t.Fatalf("mult(%d, %d) = %d, expect %d", a, b, out, expect)
+			}
+		}
+	}
+}
+
+func TestField_Divide_tables(t *testing.T) {
+	logTable, expTable := fieldTables()
+	for a := 0; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			var expect uint8
+			if a != 0 {
+				expect = expTable[(int(logTable[a])+255-int(logTable[b]))%255]
+			}
+			if out := div(uint8(a), uint8(b)); out != expect {
+				t.Fatalf("div(%d, %d) = %d, expect %d", a, b, out, expect)
+			}
+		}
+	}
+}
+
+func TestField_Inverse_tables(t *testing.T) {
+	logTable, expTable := fieldTables()
+	for a := 1; a < 256; a++ {
+		expect := expTable[(255-int(logTable[a]))%255]
+		if out := inverse(uint8(a)); out != expect {
+			t.Fatalf("inverse(%d) = %d, expect %d", a, out, expect)
+		}
+		if out := mult(uint8(a), inverse(uint8(a))); out != 1 {
+			t.Fatalf("%d * inverse(%d) = %d, expect 1", a, a, out)
+		}
+	}
+}
+
 func TestPolynomial_Random(t *testing.T) {
 	p, err := makePolynomial(42, 2)
 	if err != nil {
@@ -199,3 +259,58 @@ func TestInterpolate_Rand(t *testing.T) {
 		}
 	}
 }
+
+func TestExtend(t *testing.T) {
+	secret := []byte("test")
+
+	out, err := Split(secret, 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	used := map[byte]bool{}
+	for _, share := range out {
+		used[share[len(share)-1]] = true
+	}
+	var x uint8 = 1
+	for used[x] {
+		x++
+	}
+
+	extra, err := Extend(out[:3], x)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(extra) != len(secret)+1 || extra[len(secret)] != x {
+		t.Fatalf("bad: %v", extra)
+	}
+
+	recomb, err := Combine([][]byte{out[3], extra, out[4]})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Errorf("parts: %v", out)
+		t.Fatalf("bad: %v %v", recomb, secret)
+	}
+}
+
+func TestExtend_invalid(t *testing.T) {
+	out, err := Split([]byte("test"), 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if _, err := Extend(out[:3], 0); err == nil {
+		t.Fatalf("should err")
+	}
+
+	used := out[0][len(out[0])-1]
+	if _, err := Extend(out[:3], used); err == nil {
+		t.Fatalf("should err")
+	}
+
+	if _, err := Extend(out[:1], used); err == nil {
+		t.Fatalf("should err")
+	}
+}
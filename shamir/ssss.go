@@ -0,0 +1,289 @@
+package shamir
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+)
+
+// SsssMaxSecretSize is the largest secret of ssss, whose fields are at most GF(2^1024).
+const SsssMaxSecretSize = 128
+
+// ssssDiffusionMinSize is the smallest secret passed through the diffusion layer of ssss.
+const ssssDiffusionMinSize = 8
+
+// SplitSsss splits the secret as the ssss-split tool of B. Poettering does, over GF(2^n) for
+// n = 8·len(secret), into the shares of the x coordinates 1 to parts. Each share is the y
+// coordinate, as many bytes as the secret, which ssss writes in hex after the x coordinate.
+// With diffusion, the secret first passes the diffusion layer of ssss, which is its default.
+func SplitSsss(secret []byte, parts, threshold int, diffusion bool) ([][]byte, error) {
+	if parts < threshold {
+		return nil, fmt.Errorf("parts cannot be less than threshold")
+	}
+	if threshold < 2 {
+		return nil, fmt.Errorf("threshold must be at least 2")
+	}
+	if len(secret) == 0 || len(secret) > SsssMaxSecretSize {
+		return nil, fmt.Errorf("secret must be 1 to %d bytes long", SsssMaxSecretSize)
+	}
+	f, err := ssssField(len(secret))
+	if err != nil {
+		return nil, err
+	}
+	encoded := append([]byte(nil), secret...)
+	if diffusion {
+		ssssDiffuse(encoded, false)
+	}
+	coefficients := make([]*big.Int, threshold)
+	coefficients[0] = new(big.Int).SetBytes(encoded)
+	random := make([]byte, len(secret))
+	for i := 1; i < threshold; i++ {
+		if _, err = rand.Read(random); err != nil {
+			return nil, err
+		}
+		coefficients[i] = new(big.Int).SetBytes(random)
+	}
+	out := make([][]byte, parts)
+	for i := range out {
+		// ssss evaluates the monic polynomial x^t + c(t-1)·x^(t-1) + ... + c0.
+		x := big.NewInt(int64(i + 1))
+		y := new(big.Int).Set(x)
+		for k := threshold - 1; k > 0; k-- {
+			y = f.mul(y.Xor(y, coefficients[k]), x)
+		}
+		y.Xor(y, coefficients[0])
+		out[i] = y.FillBytes(make([]byte, len(secret)))
+	}
+	return out, nil
+}
+
+// CombineSsss reconstructs the secret from threshold shares of SplitSsss or ssss-split, given
+// the x coordinates and the y coordinates of the shares.
+func CombineSsss(xs []int, ys [][]byte, threshold int, diffusion bool) ([]byte, error) {
+	if len(xs) != len(ys) {
+		return nil, fmt.Errorf("got %d x coordinates for %d shares", len(xs), len(ys))
+	}
+	if threshold < 2 || len(ys) < threshold {
+		return nil, fmt.Errorf("need %d shares, got %d", threshold, len(ys))
+	}
+	size := len(ys[0])
+	if size == 0 || size > SsssMaxSecretSize {
+		return nil, fmt.Errorf("shares must be 1 to %d bytes long", SsssMaxSecretSize)
+	}
+	f, err := ssssField(size)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]*big.Int, threshold)
+	values := make([]*big.Int, threshold)
+	for i := range points {
+		if len(ys[i]) != size {
+			return nil, fmt.Errorf("all shares must be the same length")
+		}
+		if xs[i] <= 0 {
+			return nil, fmt.Errorf("invalid x coordinate: %d", xs[i])
+		}
+		points[i] = big.NewInt(int64(xs[i]))
+		for j := 0; j < i; j++ {
+			if points[i].Cmp(points[j]) == 0 {
+				return nil, fmt.Errorf("duplicate share detected")
+			}
+		}
+		// Strip the known leading term x^t off the monic polynomial.
+		power := big.NewInt(1)
+		for k := 0; k < threshold; k++ {
+			power = f.mul(power, points[i])
+		}
+		values[i] = power.Xor(power, new(big.Int).SetBytes(ys[i]))
+	}
+	// Lagrange interpolation at 0, where subtraction is addition.
+	secret := new(big.Int)
+	for i := range points {
+		numerator, denominator := big.NewInt(1), big.NewInt(1)
+		for j := range points {
+			if i == j {
+				continue
+			}
+			numerator = f.mul(numerator, points[j])
+			denominator = f.mul(denominator, new(big.Int).Xor(points[i], points[j]))
+		}
+		term := f.mul(values[i], f.mul(numerator, f.inverse(denominator)))
+		secret.Xor(secret, term)
+	}
+	out := secret.FillBytes(make([]byte, size))
+	if diffusion {
+		ssssDiffuse(out, true)
+	}
+	return out, nil
+}
+
+// binaryField is GF(2^degree) modulo the pentanomial x^degree + x^a + x^b + x^c + 1. The bits
+// of its elements are the coefficients of the powers of x. Unlike GF(2^8) of Split, its arithmetic
+// is not constant time.
+type binaryField struct {
+	degree, a, b, c int
+}
+
+// ssssPentanomials holds the exponents a, b and c of the pentanomials of ssss, the irred_coeff
+// table of ssss.c, by degree 8, 16, ... 1024. Each is the first irreducible one in the order of
+// a, b and c, as in Seroussi's "Table of Low-Weight Binary Irreducible Polynomials". There are no
+// irreducible trinomials of degrees divisible by 8.
+var ssssPentanomials = [SsssMaxSecretSize][3]int{
+	{4, 3, 1}, {5, 3, 1}, {4, 3, 1}, {7, 3, 2}, {5, 4, 3}, {5, 3, 2}, {7, 4, 2}, {4, 3, 1},
+	{10, 9, 3}, {9, 4, 2}, {7, 6, 2}, {10, 9, 6}, {4, 3, 1}, {5, 4, 3}, {4, 3, 1}, {7, 2, 1},
+	{5, 3, 2}, {7, 4, 2}, {6, 3, 2}, {5, 3, 2}, {15, 3, 2}, {11, 3, 2}, {9, 8, 7}, {7, 2, 1},
+	{5, 3, 2}, {9, 3, 1}, {7, 3, 1}, {9, 8, 3}, {9, 4, 2}, {8, 5, 3}, {15, 14, 10}, {10, 5, 2},
+	{9, 6, 2}, {9, 3, 2}, {9, 5, 2}, {11, 10, 1}, {7, 3, 2}, {11, 2, 1}, {9, 7, 4}, {4, 3, 1},
+	{8, 3, 1}, {7, 4, 1}, {7, 2, 1}, {13, 11, 6}, {5, 3, 2}, {7, 3, 2}, {8, 7, 5}, {12, 3, 2},
+	{13, 10, 6}, {5, 3, 2}, {5, 3, 2}, {9, 5, 2}, {9, 7, 2}, {13, 4, 3}, {4, 3, 1}, {11, 6, 4},
+	{18, 9, 6}, {19, 18, 13}, {11, 3, 2}, {15, 9, 6}, {4, 3, 1}, {16, 5, 2}, {15, 14, 6}, {8, 5, 2},
+	{15, 11, 2}, {11, 6, 2}, {7, 5, 3}, {8, 3, 1}, {19, 16, 9}, {11, 9, 6}, {15, 7, 6}, {13, 4, 3},
+	{14, 13, 3}, {13, 6, 3}, {9, 5, 2}, {19, 13, 6}, {19, 10, 3}, {11, 6, 5}, {9, 2, 1}, {14, 3, 2},
+	{13, 3, 1}, {7, 5, 4}, {11, 9, 8}, {11, 6, 5}, {23, 16, 9}, {19, 14, 6}, {23, 10, 2}, {8, 3, 2},
+	{5, 4, 3}, {9, 6, 4}, {4, 3, 2}, {13, 8, 6}, {13, 11, 1}, {13, 10, 3}, {11, 6, 5}, {19, 17, 4},
+	{15, 14, 7}, {13, 9, 6}, {9, 7, 3}, {9, 7, 1}, {14, 3, 2}, {11, 8, 2}, {11, 6, 4}, {13, 5, 2},
+	{11, 5, 1}, {11, 4, 1}, {19, 10, 3}, {21, 10, 6}, {13, 3, 1}, {15, 7, 5}, {19, 18, 10}, {7, 5, 3},
+	{12, 7, 2}, {7, 5, 1}, {14, 9, 6}, {10, 3, 2}, {15, 13, 12}, {12, 11, 9}, {16, 9, 7}, {12, 9, 3},
+	{9, 5, 2}, {17, 10, 6}, {24, 9, 3}, {17, 15, 13}, {5, 4, 3}, {19, 17, 8}, {15, 6, 3}, {19, 6, 1},
+}
+
+// ssssField returns the field of ssss for secrets of the size.
+func ssssField(size int) (binaryField, error) {
+	if size < 1 || size > SsssMaxSecretSize {
+		return binaryField{}, fmt.Errorf("no field of ssss for secrets of %d bytes", size)
+	}
+	p := ssssPentanomials[size-1]
+	return binaryField{8 * size, p[0], p[1], p[2]}, nil
+}
+
+// reduce reduces z modulo the pentanomial in place, folding the bits beyond the degree.
+func (f binaryField) reduce(z *big.Int) *big.Int {
+	high, shifted := new(big.Int), new(big.Int)
+	for z.BitLen() > f.degree {
+		high.Rsh(z, uint(f.degree))
+		z.Xor(z, shifted.Lsh(high, uint(f.degree)))
+		z.Xor(z, high)
+		z.Xor(z, shifted.Lsh(high, uint(f.a)))
+		z.Xor(z, shifted.Lsh(high, uint(f.b)))
+		z.Xor(z, shifted.Lsh(high, uint(f.c)))
+	}
+	return z
+}
+
+// mul returns the product of x and y in the field.
+func (f binaryField) mul(x, y *big.Int) *big.Int {
+	return f.reduce(clmul(x, y))
+}
+
+// inverse returns the inverse of the non-zero x in the field, with the extended Euclidean
+// algorithm over GF(2)[x].
+func (f binaryField) inverse(x *big.Int) *big.Int {
+	u, v := new(big.Int).Set(x), f.modulus()
+	g1, g2 := big.NewInt(1), new(big.Int)
+	for u.BitLen() > 1 {
+		j := u.BitLen() - v.BitLen()
+		if j < 0 {
+			u, v = v, u
+			g1, g2 = g2, g1
+			j = -j
+		}
+		u.Xor(u, new(big.Int).Lsh(v, uint(j)))
+		g1.Xor(g1, new(big.Int).Lsh(g2, uint(j)))
+	}
+	return f.reduce(g1)
+}
+
+// modulus returns the pentanomial.
+func (f binaryField) modulus() *big.Int {
+	m := big.NewInt(1)
+	for _, e := range []int{f.degree, f.a, f.b, f.c} {
+		m.SetBit(m, e, 1)
+	}
+	return m
+}
+
+// clmul returns the carry-less product of x and y, their product in GF(2)[x].
+func clmul(x, y *big.Int) *big.Int {
+	z := new(big.Int)
+	shifted := new(big.Int)
+	for i := 0; i < y.BitLen(); i++ {
+		if y.Bit(i) == 1 {
+			z.Xor(z, shifted.Lsh(x, uint(i)))
+		}
+	}
+	return z
+}
+
+// ssssDiffuse passes the big-endian field element through the diffusion layer of ssss, or back
+// with decode: 40 rounds per byte of XTEA with a zero key, on overlapping 8-byte slices of the
+// element laid out in 16-bit words, least significant first, as GMP exports it. Elements shorter
+// than 8 bytes are left alone.
+func ssssDiffuse(element []byte, decode bool) {
+	size := len(element)
+	if size < ssssDiffusionMinSize {
+		return
+	}
+	// The byte of v at i is the little-endian byte of the element at i^1, but the top byte of an
+	// odd size, which ssss moves down into the empty high byte of its last word.
+	position := func(i int) int {
+		if j := i ^ 1; j < size {
+			return size - 1 - j
+		}
+		return size - 1 - i
+	}
+	v := make([]byte, size)
+	for i := range v {
+		v[i] = element[position(i)]
+	}
+	if decode {
+		for i := 40*size - 2; i >= 0; i -= 2 {
+			ssssSlice(v, i, xteaDecipher)
+		}
+	} else {
+		for i := 0; i < 40*size; i += 2 {
+			ssssSlice(v, i, xteaEncipher)
+		}
+	}
+	for i := range v {
+		element[position(i)] = v[i]
+	}
+}
+
+// ssssSlice processes the 8 bytes of v from i on, wrapping around, as one XTEA block.
+func ssssSlice(v []byte, i int, process func(*[2]uint32)) {
+	var block [8]byte
+	for k := range block {
+		block[k] = v[(i+k)%len(v)]
+	}
+	words := [2]uint32{binary.BigEndian.Uint32(block[:4]), binary.BigEndian.Uint32(block[4:])}
+	process(&words)
+	binary.BigEndian.PutUint32(block[:4], words[0])
+	binary.BigEndian.PutUint32(block[4:], words[1])
+	for k := range block {
+		v[(i+k)%len(v)] = block[k]
+	}
+}
+
+const (
+	xteaDelta = 0x9e3779b9
+	xteaSum   = 0xc6ef3720 // xteaDelta * 32 rounds
+)
+
+func xteaEncipher(v *[2]uint32) {
+	var sum uint32
+	for i := 0; i < 32; i++ {
+		v[0] += ((v[1]<<4 ^ v[1]>>5) + v[1]) ^ sum
+		sum += xteaDelta
+		v[1] += ((v[0]<<4 ^ v[0]>>5) + v[0]) ^ sum
+	}
+}
+
+func xteaDecipher(v *[2]uint32) {
+	sum := uint32(xteaSum)
+	for i := 0; i < 32; i++ {
+		v[1] -= ((v[0]<<4 ^ v[0]>>5) + v[0]) ^ sum
+		sum -= xteaDelta
+		v[0] -= ((v[1]<<4 ^ v[1]>>5) + v[1]) ^ sum
+	}
+}
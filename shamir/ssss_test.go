@@ -0,0 +1,248 @@
+package shamir
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"math/bits"
+	"testing"
+)
+
+func TestSsssField_table(t *testing.T) {
+	for size := 1; size <= SsssMaxSecretSize; size++ {
+		f, err := ssssField(size)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !f.irreducible() {
+			t.Fatalf("degree %d: expect %v irreducible", f.degree, [3]int{f.a, f.b, f.c})
+		}
+	}
+	if testing.Short() {
+		t.Skip("skip the search of the first pentanomials in short mode")
+	}
+	// Each one is the first irreducible pentanomial in the order of a, b and c.
+	for size := 1; size <= SsssMaxSecretSize; size++ {
+		expect, _ := ssssField(size)
+		if actual := firstPentanomial(8 * size); actual != expect {
+			t.Fatalf("degree %d: expect %v, actual %v", expect.degree, [3]int{expect.a, expect.b, expect.c},
+				[3]int{actual.a, actual.b, actual.c})
+		}
+	}
+}
+
+// firstPentanomial searches the first irreducible pentanomial of the degree in the order of a, b
+// and c.
+func firstPentanomial(degree int) binaryField {
+	for a := 3; a < degree; a++ {
+		for b := 2; b < a; b++ {
+			for c := 1; c < b; c++ {
+				if f := (binaryField{degree, a, b, c}); f.irreducible() {
+					return f
+				}
+			}
+		}
+	}
+	return binaryField{}
+}
+
+// irreducible tells whether the pentanomial is irreducible with Rabin's test: it divides
+// x^(2^n) - x, and is coprime to x^(2^(n/q)) - x for every prime q dividing n. Most reducible
+// pentanomials are told early by a factor of a small degree d, dividing x^(2^k) - x for the
+// multiples k of d.
+func (f binaryField) irreducible() bool {
+	n := f.degree
+	m := f.modulus()
+	checks := make(map[int]bool)
+	// Every degree up to small has a multiple beyond small / 2.
+	small := min(16, n/2)
+	for k := small/2 + 1; k <= small; k++ {
+		checks[k] = true
+	}
+	for q, rest := 2, n; rest > 1; q++ {
+		if rest%q == 0 {
+			checks[n/q] = true
+			for rest%q == 0 {
+				rest /= q
+			}
+		}
+	}
+	x := big.NewInt(2)
+	r := new(big.Int).Set(x)
+	for k := 1; k <= n; k++ {
+		r = f.reduce(square(r))
+		if checks[k] && polyGcd(new(big.Int).Xor(r, x), m).BitLen() != 1 {
+			return false
+		}
+	}
+	return r.Cmp(x) == 0
+}
+
+// square returns the square of x in GF(2)[x], which spreads its bits apart.
+func square(x *big.Int) *big.Int {
+	const half = bits.UintSize / 2
+	in := x.Bits()
+	out := make([]big.Word, 2*len(in))
+	for i, w := range in {
+		out[2*i] = spread(w & (1<<half - 1))
+		out[2*i+1] = spread(w >> half)
+	}
+	return new(big.Int).SetBits(out)
+}
+
+// spread moves the bit i of the lower half of w to the bit 2i.
+func spread(w big.Word) big.Word {
+	u := uint64(w)
+	u = (u | u<<16) & 0x0000ffff0000ffff
+	u = (u | u<<8) & 0x00ff00ff00ff00ff
+	u = (u | u<<4) & 0x0f0f0f0f0f0f0f0f
+	u = (u | u<<2) & 0x3333333333333333
+	u = (u | u<<1) & 0x5555555555555555
+	return big.Word(u)
+}
+
+// polyGcd returns the greatest common divisor of a and b in GF(2)[x].
+func polyGcd(a, b *big.Int) *big.Int {
+	a, b = new(big.Int).Set(a), new(big.Int).Set(b)
+	for b.Sign() != 0 {
+		for a.BitLen() >= b.BitLen() {
+			a.Xor(a, new(big.Int).Lsh(b, uint(a.BitLen()-b.BitLen())))
+		}
+		a, b = b, a
+	}
+	return a
+}
+
+func TestSsssField_aes(t *testing.T) {
+	// GF(2^8) of ssss is the field of Split.
+	f, err := ssssField(1)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for a := 0; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			actual := f.mul(big.NewInt(int64(a)), big.NewInt(int64(b)))
+			if expect := mult(uint8(a), uint8(b)); actual.Int64() != int64(expect) {
+				t.Fatalf("%d * %d: expect %d, actual %d", a, b, expect, actual)
+			}
+		}
+		if a > 0 {
+			if actual := f.inverse(big.NewInt(int64(a))); actual.Int64() != int64(inverse(uint8(a))) {
+				t.Fatalf("inverse of %d: expect %d, actual %d", a, inverse(uint8(a)), actual)
+			}
+		}
+	}
+}
+
+func TestSsssField_inverse(t *testing.T) {
+	for _, size := range []int{8, 23, 32, 128} {
+		f, err := ssssField(size)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		buf := make([]byte, size)
+		if _, err = rand.Read(buf); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		x := new(big.Int).SetBytes(buf)
+		if x.Sign() == 0 {
+			continue
+		}
+		if product := f.mul(x, f.inverse(x)); product.Cmp(big.NewInt(1)) != 0 {
+			t.Fatalf("size %d: x * x^-1 = %x", size, product)
+		}
+	}
+}
+
+func TestSsssDiffuse(t *testing.T) {
+	for _, size := range []int{7, 8, 9, 16, 23, 128} {
+		element := make([]byte, size)
+		if _, err := rand.Read(element); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		encoded := bytes.Clone(element)
+		ssssDiffuse(encoded, false)
+		if size >= ssssDiffusionMinSize && bytes.Equal(encoded, element) {
+			t.Fatalf("size %d: not diffused", size)
+		}
+		ssssDiffuse(encoded, true)
+		if !bytes.Equal(encoded, element) {
+			t.Fatalf("size %d: expect %x, actual %x", size, element, encoded)
+		}
+	}
+}
+
+func TestCombineSsss_manual(t *testing.T) {
+	// The example of the ssss manual page, split with the default diffusion.
+	shares := map[int]string{
+		1: "1c41ef496eccfbeba439714085df8437236298da8dd824",
+		2: "fbc74a03a50e14ab406c225afb5f45c40ae11976d2b665",
+		3: "fa1c3a9c6df8af0779c36de6c33f6e36e989d0e0b91309",
+		4: "468de7d6eb36674c9cf008c8e8fc8c566537ad6301eb9e",
+		5: "4756974923c0dce0a55f4774d09ca7a4865f64f56a4ee0",
+	}
+	for _, xs := range [][]int{{3, 5, 2}, {1, 2, 3}, {1, 4, 5}} {
+		var ys [][]byte
+		for _, x := range xs {
+			y, err := hex.DecodeString(shares[x])
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			ys = append(ys, y)
+		}
+		secret, err := CombineSsss(xs, ys, 3, true)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if string(secret) != "my secret root password" {
+			t.Fatalf("shares %v: bad secret %q", xs, secret)
+		}
+	}
+}
+
+func TestSplitSsss(t *testing.T) {
+	for _, size := range []int{1, 7, 8, 9, 23, 32, 128} {
+		secret := make([]byte, size)
+		if _, err := rand.Read(secret); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		for _, diffusion := range []bool{false, true} {
+			out, err := SplitSsss(secret, 5, 3, diffusion)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			for _, xs := range [][]int{{1, 2, 3}, {5, 3, 1}, {2, 4, 5}} {
+				ys := [][]byte{out[xs[0]-1], out[xs[1]-1], out[xs[2]-1]}
+				recombined, err := CombineSsss(xs, ys, 3, diffusion)
+				if err != nil {
+					t.Fatalf("err: %v", err)
+				}
+				if !bytes.Equal(recombined, secret) {
+					t.Fatalf("size %d, diffusion %v, shares %v: expect %x, actual %x",
+						size, diffusion, xs, secret, recombined)
+				}
+			}
+		}
+	}
+}
+
+func TestSplitSsss_invalid(t *testing.T) {
+	secret := []byte("test")
+
+	if _, err := SplitSsss(secret, 2, 3, true); err == nil {
+		t.Fatalf("expect error")
+	}
+
+	if _, err := SplitSsss(secret, 3, 1, true); err == nil {
+		t.Fatalf("expect error")
+	}
+
+	if _, err := SplitSsss(nil, 3, 2, true); err == nil {
+		t.Fatalf("expect error")
+	}
+
+	if _, err := SplitSsss(make([]byte, SsssMaxSecretSize+1), 3, 2, true); err == nil {
+		t.Fatalf("expect error")
+	}
+}
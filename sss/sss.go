@@ -1,21 +1,121 @@
 package sss
 
 import (
+	"fmt"
 	"os"
+	"slices"
 	"time"
 )
 
+// SchemeFeldman marks parts split over a prime order group with Feldman commitments.
+// Parts without a scheme are split over GF(2^8).
+const SchemeFeldman = "feldman"
+
+// SchemeIda marks parts of a file encrypted with a random key, whose ciphertext is dispersed
+// among the parts with Rabin's information dispersal, and whose key is split over GF(2^8).
+const SchemeIda = "ida"
+
+// SchemeSsss marks parts of the ssss-split tool, split over GF(2^n) for n = 8 * the secret size.
+const SchemeSsss = "ssss"
+
+// blindingSize is the number of random bytes appended to a secret before it is split. They key
+// the digest of the secret, so that a single part does not allow confirming a guessed secret.
+const blindingSize = 32
+
 const fileBlockSize = 512 * 1024
 const maxScannerTokenSize = 768 * 1024
 
 type Part struct {
-	Payload   string    `json:"payload"`
-	Block     int       `json:"block"`
-	Blocks    int       `json:"blocks"`
-	Part      int       `json:"part"`
-	Parts     uint8     `json:"parts"`
-	Threshold uint8     `json:"threshold"`
-	Digest    string    `json:"digest"`
-	Timestamp time.Time `json:"timestamp"`
+	Payload     string   `json:"payload"`
+	Block       int      `json:"block"`
+	Blocks      int      `json:"blocks"`
+	Part        int      `json:"part"`
+	Parts       uint8    `json:"parts"`
+	Threshold   uint8    `json:"threshold"`
+	Digest      string   `json:"digest"`
+	Blinding    int      `json:"blinding,omitempty"`
+	Set         string   `json:"set,omitempty"`
+	SetMac      string   `json:"set_mac,omitempty"`
+	Coordinates string   `json:"coordinates,omitempty"`
+	Scheme      string   `json:"scheme,omitempty"`
+	Commitments []string `json:"commitments,omitempty"`
+	// Weighted sharing: Points holds the share points of the part beyond Payload, and Weights the
+	// number of points of every part of the set, by part number.
+	Points  []string `json:"points,omitempty"`
+	Weights string   `json:"weights,omitempty"`
+	// Group sharing: the secret is split among the groups described by Groups, any GroupThreshold
+	// of which recover it, and the share of group number Group is split among its members. Part,
+	// Parts and Threshold then count the members of the group.
+	Group          int    `json:"group,omitempty"`
+	Groups         string `json:"groups,omitempty"`
+	GroupThreshold uint8  `json:"group_threshold,omitempty"`
+	// Dispersal: Key holds the share of the key, Payload the share of the ciphertext of the block,
+	// and Size the length of the ciphertext.
+	Key  string `json:"key,omitempty"`
+	Size int    `json:"size,omitempty"`
+	// ssss interop: Diffusion tells that the secret passed the diffusion layer of ssss before it was split.
+	Diffusion bool `json:"diffusion,omitempty"`
+	// Sealing: Payload and Points are encrypted with a key derived from the passphrase of the
+	// holder as described by Seal.
+	Seal *Seal `json:"seal,omitempty"`
+	// Dealer signature: Signature is the ed25519 signature of the part by the dealer whose public key
+	// has the fingerprint Dealer.
+	Dealer    string `json:"dealer,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	// Free-form descriptions, which unlike the set are not authenticated.
+	Labels    []string   `json:"labels,omitempty"`
+	Holder    string     `json:"holder,omitempty"`
+	NotAfter  *time.Time `json:"not_after,omitempty"`
+	Timestamp time.Time  `json:"timestamp"`
 	file      *os.File
+	// digestCheck and setMacCheck hold the first bytes of the secret digest and of the set
+	// authenticator carried by the words of a part.
+	digestCheck []byte
+	setMacCheck []byte
+}
+
+// Kinds of secret shares which not every operation supports.
+const (
+	kindGroup      = "group"
+	kindDispersed  = "dispersed"
+	kindSsss       = "ssss"
+	kindVerifiable = "verifiable"
+	kindWeighted   = "weighted"
+	kindBlocks     = "multi-block"
+)
+
+// shareKinds returns the kinds of the secret shares of a part, none for plain secret shares.
+func shareKinds(p Part) (kinds []string) {
+	if len(p.Groups) > 0 {
+		kinds = append(kinds, kindGroup)
+	}
+	switch p.Scheme {
+	case SchemeIda:
+		kinds = append(kinds, kindDispersed)
+	case SchemeSsss:
+		kinds = append(kinds, kindSsss)
+	case SchemeFeldman:
+		kinds = append(kinds, kindVerifiable)
+	}
+	if len(p.Points) > 0 {
+		kinds = append(kinds, kindWeighted)
+	}
+	if p.Blocks > 1 {
+		kinds = append(kinds, kindBlocks)
+	}
+	return
+}
+
+// unsupported tells that the operation op is unsupported for the parts, unless their secret shares
+// are plain or of the supported kinds.
+func unsupported(parts []Part, op string, supported ...string) error {
+	if len(parts) == 0 {
+		return nil
+	}
+	for _, kind := range shareKinds(parts[0]) {
+		if !slices.Contains(supported, kind) {
+			return fmt.Errorf("%s is unsupported for %s secret shares", op, kind)
+		}
+	}
+	return nil
 }
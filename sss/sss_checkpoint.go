@@ -0,0 +1,113 @@
+package sss
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// checkpointInterval is the least time between two saves of a checkpoint.
+const checkpointInterval = time.Second
+
+// checkpoint records how many blocks of a split or a combine are written, and the lengths of the
+// output files after them, so that an interrupted run can be resumed. It holds no secret.
+type checkpoint struct {
+	Inputs    []string  `json:"inputs"`
+	Size      int64     `json:"size,omitempty"`
+	ModTime   time.Time `json:"mod_time,omitempty"`
+	Set       string    `json:"set"`
+	Parts     uint8     `json:"parts,omitempty"`
+	Threshold uint8     `json:"threshold,omitempty"`
+	Ida       bool      `json:"ida,omitempty"`
+	Blocks    int       `json:"blocks"`
+	Done      int       `json:"done"`
+	Lengths   []int64   `json:"lengths"`
+	path      string
+	saved     time.Time
+}
+
+// loadCheckpoint reads the checkpoint of an interrupted run.
+func loadCheckpoint(path string) (*checkpoint, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no checkpoint to resume from: %s", path)
+	} else if err != nil {
+		return nil, err
+	}
+	c := &checkpoint{path: path}
+	if err = json.Unmarshal(content, c); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %v", path, err)
+	}
+	return c, nil
+}
+
+// matches reports whether the checkpoint was written for the same inputs and parameters.
+func (c *checkpoint) matches(o *checkpoint) bool {
+	return slices.Equal(c.Inputs, o.Inputs) && c.Size == o.Size && c.ModTime.Equal(o.ModTime) &&
+		c.Parts == o.Parts && c.Threshold == o.Threshold && c.Ida == o.Ida && c.Blocks == o.Blocks &&
+		len(c.Lengths) == len(o.Lengths)
+}
+
+// update records that one more block is written, with the output files at the given lengths, and
+// saves the checkpoint at most every checkpointInterval, after syncing the output files.
+func (c *checkpoint) update(outputs []*os.File) error {
+	c.Done++
+	for i, file := range outputs {
+		length, err := file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		c.Lengths[i] = length
+	}
+	if time.Since(c.saved) < checkpointInterval {
+		return nil
+	}
+	for _, file := range outputs {
+		if err := file.Sync(); err != nil {
+			return err
+		}
+	}
+	return c.save()
+}
+
+// save writes the checkpoint into a temporary file renamed over the previous one.
+func (c *checkpoint) save() error {
+	content, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	temp := c.path + ".tmp"
+	if err = os.WriteFile(temp, content, 0600); err != nil {
+		return err
+	}
+	if err = os.Rename(temp, c.path); err != nil {
+		return err
+	}
+	c.saved = time.Now()
+	return nil
+}
+
+// remove deletes the checkpoint of a completed run.
+func (c *checkpoint) remove() error {
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// absPaths returns the absolute paths of the files, by which checkpoints name their inputs.
+func absPaths(paths ...string) ([]string, error) {
+	out := make([]string, len(paths))
+	for i, path := range paths {
+		var err error
+		if out[i], err = filepath.Abs(path); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,204 @@
+package sss
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// interruptSplit turns the share files of a complete split into those of a split interrupted
+// after the given blocks: the files are left as they are, longer than the checkpoint tells, as
+// when blocks were written after the checkpoint was last saved.
+func interruptSplit(t *testing.T, in, prefix string, parts, threshold uint8, ida bool, done int) {
+	t.Helper()
+	stat, err := os.Stat(in)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	cp := &checkpoint{
+		Size:      stat.Size(),
+		ModTime:   stat.ModTime(),
+		Parts:     parts,
+		Threshold: threshold,
+		Ida:       ida,
+		Blocks:    int((stat.Size() + fileBlockSize - 1) / fileBlockSize),
+		Done:      done,
+		Lengths:   make([]int64, parts),
+		path:      prefix + "checkpoint.json",
+	}
+	if cp.Inputs, err = absPaths(in); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := range cp.Lengths {
+		content, err := os.ReadFile(fmt.Sprintf("%s%dof%d.json", prefix, i+1, parts))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		lines := bytes.SplitAfter(content, []byte("\n\n"))
+		var p Part
+		if err = json.Unmarshal(lines[0], &p); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		cp.Set = p.Set
+		for _, line := range lines[:done] {
+			cp.Lengths[i] += int64(len(bytes.TrimSuffix(line, []byte("\n\n"))))
+		}
+		cp.Lengths[i] += int64(2 * (done - 1))
+	}
+	if err = cp.save(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestSplitIntoFiles_resume(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.data")
+	secret := make([]byte, 2*fileBlockSize+1000)
+	if _, err := rand.Read(secret); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := os.WriteFile(in, secret, 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, ida := range []bool{false, true} {
+		prefix := filepath.Join(dir, fmt.Sprintf("ida-%v-", ida))
+		// Nothing to resume from
+		if err := SplitIntoFiles(in, 3, 2, prefix, ida, true, true, false); err == nil {
+			t.Fatalf("expect error")
+		}
+		CloseAllFilesForWrite()
+		if err := SplitIntoFiles(in, 3, 2, prefix, ida, false, true, false); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		CloseAllFilesForWrite()
+		interruptSplit(t, in, prefix, 3, 2, ida, 1)
+		if err := SplitIntoFiles(in, 3, 2, prefix, ida, true, true, false); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		CloseAllFilesForWrite()
+		if _, err := os.Stat(prefix + "checkpoint.json"); !os.IsNotExist(err) {
+			t.Fatalf("expect checkpoint removed, actual %v", err)
+		}
+		out := prefix + "out.data"
+		paths := []string{prefix + "3of3.json", prefix + "1of3.json"}
+		if err := CombinePartFiles(paths, out, false, true, false, false); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		recombined, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !bytes.Equal(recombined, secret) {
+			t.Fatalf("ida %v: expect %d bytes, actual %d bytes differing", ida, len(secret), len(recombined))
+		}
+	}
+
+	// A checkpoint of another input file
+	prefix := filepath.Join(dir, "changed-")
+	if err := SplitIntoFiles(in, 3, 2, prefix, false, false, true, false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	CloseAllFilesForWrite()
+	interruptSplit(t, in, prefix, 3, 2, false, 1)
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(in, later, later); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := SplitIntoFiles(in, 3, 2, prefix, false, true, true, false); err == nil {
+		t.Fatalf("expect error")
+	}
+	CloseAllFilesForWrite()
+}
+
+func TestSplitIntoFiles_empty(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.data")
+	if err := os.WriteFile(in, nil, 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	prefix := filepath.Join(dir, "empty-")
+	// The empty input file is refused on opening, before any share file is written.
+	err := SplitIntoFiles(in, 3, 2, prefix, false, false, true, false)
+	if err == nil || !strings.HasSuffix(err.Error(), "is empty") {
+		t.Fatalf("expect error of an empty file, actual %v", err)
+	}
+	CloseAllFilesForWrite()
+	if _, err = os.Stat(prefix + "1of3.json"); !os.IsNotExist(err) {
+		t.Fatalf("expect no share file, actual %v", err)
+	}
+}
+
+func TestCombinePartFiles_resume(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.data")
+	secret := make([]byte, 3*fileBlockSize)
+	if _, err := rand.Read(secret); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := os.WriteFile(in, secret, 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	prefix := filepath.Join(dir, "part-")
+	if err := SplitIntoFiles(in, 3, 2, prefix, false, false, true, false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	CloseAllFilesForWrite()
+	paths := []string{prefix + "1of3.json", prefix + "2of3.json"}
+	out := filepath.Join(dir, "out.data")
+	if err := CombinePartFiles(paths, out, true, true, false, false); err == nil {
+		t.Fatalf("expect error")
+	}
+	if err := CombinePartFiles(paths, out, false, true, false, false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Interrupted after the first block, with the second written after the checkpoint was saved
+	file, err := os.Open(paths[0])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var first Part
+	reader := bufio.NewReader(file)
+	line, _ := reader.ReadBytes('\n')
+	_ = file.Close()
+	if err = json.Unmarshal(line, &first); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	cp := &checkpoint{Set: first.Set, Blocks: first.Blocks, Done: 1, Lengths: []int64{fileBlockSize},
+		path: out + ".checkpoint.json"}
+	if cp.Inputs, err = absPaths(paths...); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err = os.Truncate(out, 2*fileBlockSize); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err = cp.save(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The checkpoint is of other input files.
+	others := []string{prefix + "1of3.json", prefix + "3of3.json"}
+	if err = CombinePartFiles(others, out, true, false, false, false); err == nil {
+		t.Fatalf("expect error")
+	}
+	if err = CombinePartFiles(paths, out, true, false, false, false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	recombined, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recombined, secret) {
+		t.Fatalf("expect %d bytes, actual %d bytes differing", len(secret), len(recombined))
+	}
+	if _, err = os.Stat(cp.path); !os.IsNotExist(err) {
+		t.Fatalf("expect checkpoint removed, actual %v", err)
+	}
+}
@@ -2,48 +2,226 @@ package sss
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/wangkang/fortify/files"
 	"github.com/wangkang/fortify/shamir"
 	"github.com/wangkang/fortify/utils"
 )
 
+// Combine reconstructs the secret from the parts and checks it against the digest they carry. The
+// parts of a dispersed block reconstruct the block.
 func Combine(parts []Part) ([]byte, error) {
+	if err := checkVerifiable(parts); err != nil {
+		return nil, err
+	}
+	if len(parts) > 0 && parts[0].Scheme == SchemeIda {
+		return combineIda(parts)
+	}
+	raw, err := combineRaw(parts)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.Wipe(raw)
+	return unblind(parts, raw)
+}
+
+// combineRaw reconstructs the secret from the parts, still carrying its blinding bytes.
+func combineRaw(parts []Part) ([]byte, error) {
+	if err := checkVerifiable(parts); err != nil {
+		return nil, err
+	}
+	if len(parts) > 0 && parts[0].Scheme == SchemeSsss {
+		return combineSsss(parts)
+	}
+	if len(parts) > 0 && len(parts[0].Groups) > 0 {
+		return combineGroups(parts)
+	}
+	if len(parts) > 0 && totalWeight(parts) < int(parts[0].Threshold) {
+		return nil, fmt.Errorf("need %d secret shares, got %d", parts[0].Threshold, totalWeight(parts))
+	}
+	shares, err := decodeShares(parts)
+	if err != nil {
+		return nil, err
+	}
+	return combineShares(parts, shares)
+}
+
+// unblind strips the blinding bytes from a reconstructed secret and verifies its digest and the
+// authenticity of the set of the parts. Parts without blinding carry the plain SHA-512 digest of
+// the secret, and old parts no digest at all.
+func unblind(parts []Part, raw []byte) ([]byte, error) {
+	first := parts[0]
+	if first.Blinding < 0 || first.Blinding > len(raw) {
+		return nil, fmt.Errorf("invalid secret blinding size: %d", first.Blinding)
+	}
+	size := len(raw) - first.Blinding
+	actual := secretDigest(first, raw)
+	if len(first.Digest) > 0 && actual != first.Digest {
+		fmt.Printf("Expect secret digest: %s\n", first.Digest)
+		fmt.Printf("Actual secret digest: %s\n", actual)
+		return nil, errors.New("secret digest mismatch")
+	}
+	if err := authenticateSet(parts, raw); err != nil {
+		return nil, err
+	}
+	return slices.Clone(raw[:size]), nil
+}
+
+// secretDigest computes the digest of a reconstructed secret carrying the blinding bytes of the part.
+func secretDigest(p Part, raw []byte) string {
+	size := len(raw) - p.Blinding
+	if p.Blinding > 0 {
+		return utils.ComputeKeyedDigest(raw[size:], raw[:size])
+	}
+	return utils.ComputeDigest(raw)
+}
+
+// combineShares reconstructs the secret with the scheme of the parts. Verifiable parts are
+// checked against their commitments first, so that a bad part is named instead of yielding garbage.
+func combineShares(parts []Part, shares [][]byte) ([]byte, error) {
+	if len(parts) == 0 || parts[0].Scheme != SchemeFeldman {
+		return shamir.Combine(shares)
+	}
+	if len(shares) != len(parts) {
+		return nil, errors.New("verifiable secret shares cannot be weighted")
+	}
+	if err := verifyShares(parts, shares); err != nil {
+		return nil, err
+	}
+	if c := publishedCommitments(); c != nil && len(shares) < len(c.Commitments) {
+		return nil, fmt.Errorf("need %d secret shares, got %d", len(c.Commitments), len(shares))
+	}
+	return shamir.CombineVerifiable(shares)
+}
+
+// verifyShares checks every verifiable part against the published commitments if set, or else
+// against the commitments carried by most of the parts.
+func verifyShares(parts []Part, shares [][]byte) error {
+	if c := publishedCommitments(); c != nil {
+		commitments, err := decodeCommitments(c.Commitments)
+		if err != nil {
+			return err
+		}
+		for i, p := range parts {
+			if len(c.Set) > 0 && p.Set != c.Set {
+				return fmt.Errorf("secret share in file %v belongs to set %s, not to the set %s of the published commitments",
+					i+1, p.Set, c.Set)
+			}
+			if err = shamir.VerifyShare(shares[i], commitments); err != nil {
+				return fmt.Errorf("secret share in file %v does not match the published commitments: %v", i+1, err)
+			}
+		}
+		return nil
+	}
+	counts := make(map[string]int)
+	var reference string
+	for _, p := range parts {
+		key := strings.Join(p.Commitments, ",")
+		counts[key]++
+		if counts[key] > counts[reference] {
+			reference = key
+		}
+	}
+	if len(reference) == 0 {
+		return errors.New("verifiable secret shares carry no commitments")
+	}
+	commitments, err := decodeCommitments(strings.Split(reference, ","))
+	if err != nil {
+		return err
+	}
+	for i, p := range parts {
+		if strings.Join(p.Commitments, ",") != reference {
+			return fmt.Errorf("secret share in file %v carries commitments different from the other files", i+1)
+		}
+		if err = shamir.VerifyShare(shares[i], commitments); err != nil {
+			return fmt.Errorf("secret share in file %v is invalid: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// decodeShares decodes the payloads of parts, which must all belong to the same secret share set.
+func decodeShares(parts []Part) ([][]byte, error) {
 	var (
-		secret []byte
-		expect string
+		expect    string
+		set       string
+		threshold uint8
 	)
-	shares := make([][]byte, len(parts))
+	for index, i := range parts {
+		if i.Scheme != parts[0].Scheme {
+			return nil, fmt.Errorf("secret sharing scheme mismatch in file %v: expect %q, actual %q",
+				index+1, parts[0].Scheme, i.Scheme)
+		}
+	}
+	shares := make([][]byte, len(parts), totalWeight(parts))
 	for index, i := range parts {
 		if share, err := base64.URLEncoding.DecodeString(i.Payload); err != nil {
-			return secret, err
+			return nil, err
 		} else {
 			shares[index] = share
+			if len(i.Set) > 0 {
+				if len(set) == 0 {
+					set = i.Set
+				} else if set != i.Set {
+					return nil, fmt.Errorf("secret share set mismatch in file %v: expect %s, actual %s",
+						index+1, set, i.Set)
+				}
+			}
+			if threshold == 0 {
+				threshold = i.Threshold
+			} else if threshold != i.Threshold {
+				return nil, fmt.Errorf("secret share threshold mismatch in file %v: expect %d, actual %d",
+					index+1, threshold, i.Threshold)
+			}
 			if len(expect) == 0 {
 				expect = i.Digest
 			} else {
 				if expect != i.Digest {
 					fmt.Printf("Expect secret digest: %s\n", expect)
 					fmt.Printf("Actual secret digest: %s\n", i.Digest)
-					return secret, fmt.Errorf("secret digest mismatch in file %v", index+1)
+					return nil, fmt.Errorf("secret digest mismatch in file %v", index+1)
 				}
 			}
 		}
 	}
-	var err error
-	if secret, err = shamir.Combine(shares); err != nil {
-		return secret, err
+	// The further points of weighted parts follow the first point of every part.
+	for _, i := range parts {
+		for _, point := range i.Points {
+			share, err := base64.URLEncoding.DecodeString(point)
+			if err != nil {
+				return nil, err
+			}
+			shares = append(shares, share)
+		}
 	}
-	return secret, nil
+	return shares, nil
+}
+
+// CombineKeyFiles reads the key parts in the leading arguments, up to the first one that is not a
+// file. A key part file holds a part as JSON, as words or as recovery kit text, and "-" reads words
+// or recovery kit text from the standard input. Key parts are never taken from the arguments
+// themselves, which would expose them in the process list and the shell history. Sealed parts are
+// unsealed with the passphrases of their holders.
+func CombineKeyFiles(args []string) ([]Part, error) {
+	return readKeyFiles(args, true)
 }
 
-func CombineKeyFiles(args []string) (parts []Part, err error) {
+// ReadSealedKeyFiles reads the key parts in the leading arguments as CombineKeyFiles does, but keeps
+// sealed parts sealed, so that they can be written elsewhere without their shares exposed.
+func ReadSealedKeyFiles(args []string) ([]Part, error) {
+	return readKeyFiles(args, false)
+}
+
+func readKeyFiles(args []string, unseal bool) (parts []Part, err error) {
 	size := len(args)
 	if size == 0 {
 		return nil, nil
@@ -51,40 +229,219 @@ func CombineKeyFiles(args []string) (parts []Part, err error) {
 	kCloseFns := make([]func(), size)
 	kParts := make([]Part, size)
 	count := 0
-	for i, name := range args {
-		var kf *os.File
-		if kf, kCloseFns[i], err = files.OpenInputFile(name); err != nil {
-			break
+	defer func() {
+		for _, kCloseFn := range kCloseFns[:count] {
+			kCloseFn()
 		}
-		count++
+	}()
+	var stdin *bufio.Reader
+	for i, name := range args {
 		var kb []byte
-		if kb, err = io.ReadAll(kf); err != nil {
+		if name == "-" {
+			if stdin == nil {
+				stdin = bufio.NewReader(os.Stdin)
+			}
+			kCloseFns[i] = func() {}
+			count++
+			var text string
+			if text, err = readWordsFromStdin(stdin, i+1); err != nil {
+				return
+			}
+			kb = []byte(text)
+		} else {
+			var kf *os.File
+			if kf, kCloseFns[i], err = files.OpenInputFile(name); err != nil {
+				break
+			}
+			count++
+			if kb, err = io.ReadAll(kf); err != nil {
+				return
+			}
+		}
+		if kParts[i], err = parseKeyPart(name, kb); err != nil {
 			return
 		}
-		if err = json.Unmarshal(kb, &kParts[i]); err != nil {
-			err = fmt.Errorf("not a valid sss key part\nCaused by: %v", err)
+		if err = verifyDealer(kParts[i], name); err != nil {
 			return
 		}
+		if unseal && kParts[i].Seal != nil {
+			if err = unsealPart(&kParts[i], name); err != nil {
+				return
+			}
+		}
 	}
-	kCloseFns = kCloseFns[:count]
-	defer func() {
-		for _, kCloseFn := range kCloseFns {
-			kCloseFn()
+	parts = kParts[:count]
+	if err = checkKeyParts(args[:count], parts); err != nil {
+		return nil, err
+	}
+	if err = restoreDigest(parts); err != nil {
+		return nil, err
+	}
+	return parts, nil
+}
+
+// parseKeyPart parses the content of a key part file, holding a part as JSON, words or recovery kit text.
+func parseKeyPart(name string, kb []byte) (p Part, err error) {
+	if IsKitText(kb) {
+		return ParseKitText(string(kb))
+	} else if IsWords(kb) {
+		if p, err = DecodeWords(ParseWords(string(kb))); err != nil {
+			err = fmt.Errorf("not a valid sss key part: %s\n%v", name, err)
 		}
-	}()
-	return kParts[:count], nil
+	} else if err = json.Unmarshal(kb, &p); err != nil {
+		err = fmt.Errorf("not a valid sss key part\nCaused by: %v", err)
+	}
+	return
 }
 
-func CombinePartFiles(in []string, out string, truncate, verbose bool) error {
+// restoreDigest fills in the digest of parts which do not carry one, such as parts read from words,
+// by combining them, and checks it against the first bytes of the digest carried by words. It leaves
+// the parts alone if they are not enough to combine, or still sealed.
+func restoreDigest(parts []Part) error {
+	if len(parts) == 0 || totalWeight(parts) < int(parts[0].Threshold) {
+		return nil
+	}
+	if slices.ContainsFunc(parts, func(p Part) bool { return p.Seal != nil }) {
+		return nil
+	}
+	var digest string
+	missing := false
+	for i, p := range parts {
+		if len(p.Digest) == 0 {
+			missing = true
+		} else if len(digest) == 0 {
+			digest = p.Digest
+		} else if digest != p.Digest {
+			return fmt.Errorf("secret digest mismatch in file %v", i+1)
+		}
+	}
+	if !missing {
+		return nil
+	}
+	first := parts[0]
+	first.Digest = digest
+	// The digest is checked once the secret is combined, not against the parts lacking it.
+	blank := slices.Clone(parts)
+	for i := range blank {
+		blank[i].Digest = ""
+	}
+	raw, err := combineRaw(blank)
+	if err != nil {
+		return err
+	}
+	defer utils.Wipe(raw)
+	var secret []byte
+	if secret, err = unblind(append([]Part{first}, parts[1:]...), raw); err != nil {
+		return err
+	}
+	defer utils.Wipe(secret)
+	if len(digest) == 0 {
+		digest = secretDigest(first, raw)
+	}
+	decoded, _ := base64.URLEncoding.DecodeString(digest)
+	for i := range parts {
+		if check := parts[i].digestCheck; len(check) > 0 && !bytes.HasPrefix(decoded, check) {
+			return fmt.Errorf("secret digest mismatch in file %v: the recovered secret does not match its words", i+1)
+		}
+		parts[i].Digest = digest
+	}
+	return nil
+}
+
+// combineParts combines the parts read from the files, robustly if asked, warning about the
+// files holding parts inconsistent with the recovered secret.
+func combineParts(parts []Part, names []string, robust bool) ([]byte, error) {
+	if !robust {
+		return Combine(parts)
+	}
+	secret, bad, err := CombineRobust(parts)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range bad {
+		fmt.Printf("Warning: secret share in file %s is inconsistent with the recovered secret and was ignored\n", names[i])
+	}
+	return secret, nil
+}
+
+// combineKeyPartFiles combines single block key parts, such as parts written as words, into the output.
+func combineKeyPartFiles(in []string, out string, truncate, verbose, robust bool) error {
+	parts, err := CombineKeyFiles(in)
+	if err != nil {
+		return err
+	}
+	if len(parts) != len(in) {
+		return fmt.Errorf("cannot open input file: %s", in[len(parts)])
+	}
+	var secret []byte
+	if secret, err = combineParts(parts, in, robust); err != nil {
+		return err
+	}
+	defer utils.Wipe(secret)
+	output, oCloseFn, err := files.OpenOutputFile(out, truncate)
+	if err != nil {
+		return err
+	}
+	defer oCloseFn()
+	if err = output.Truncate(0); err != nil {
+		return err
+	}
+	if _, err = output.Write(secret); err != nil {
+		return err
+	}
+	if verbose {
+		fmt.Printf("Recovered %d bytes from %d key parts into %s\n", len(secret), len(parts), out)
+	}
+	return nil
+}
+
+// isTextKeyPart reports whether the input is a key part written as words or recovery kit text.
+func isTextKeyPart(name string) bool {
+	if name == "-" {
+		return true
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = file.Close() }()
+	b := make([]byte, 512)
+	n, _ := io.ReadFull(file, b)
+	return IsWords(b[:n]) || IsKitText(b[:n])
+}
+
+func CombinePartFiles(in []string, out string, resume, truncate, verbose, robust bool) error {
 	size := len(in)
 	if size == 0 {
 		return errors.New("no input files")
 	}
+	if slices.ContainsFunc(in, isTextKeyPart) || slices.ContainsFunc(in, isSealedKeyPart) {
+		return combineKeyPartFiles(in, out, truncate, verbose, robust)
+	}
+	// The checkpoint records the blocks written into the output file, so that an interrupted
+	// combine can go on from there with resume.
+	var cp *checkpoint
 	var output *os.File = nil
-	var oCloseFn func()
+	var oCloseFn = func() {}
 	if len(out) > 0 {
+		cp = &checkpoint{Lengths: make([]int64, 1), path: out + ".checkpoint.json"}
 		var err error
-		if output, oCloseFn, err = files.OpenOutputFile(out, truncate); err != nil {
+		if cp.Inputs, err = absPaths(in...); err != nil {
+			return err
+		}
+		if resume {
+			var saved *checkpoint
+			if saved, err = loadCheckpoint(cp.path); err != nil {
+				return err
+			}
+			if !slices.Equal(saved.Inputs, cp.Inputs) {
+				return fmt.Errorf("checkpoint %s does not match the input files", cp.path)
+			}
+			cp.Set, cp.Blocks, cp.Done, cp.Lengths = saved.Set, saved.Blocks, saved.Done, saved.Lengths
+			if output, oCloseFn, err = files.ResumeOutputFile(out, cp.Lengths[0]); err != nil {
+				return err
+			}
+		} else if output, oCloseFn, err = files.OpenOutputFile(out, truncate); err != nil {
 			return err
 		}
 	}
@@ -112,83 +469,103 @@ func CombinePartFiles(in []string, out string, truncate, verbose bool) error {
 		scanners[i].Split(bufio.ScanLines)
 	}
 
-	parts := make([]Part, size)
-	count := 0
-	for {
-		var err error
-		var lines [][]byte
-		for _, scanner := range scanners {
-			if scanner.Scan() {
-				line := scanner.Bytes()
-				lines = append(lines, line)
+	// Blocks are read in order, combined in parallel, and written in order.
+	type combined struct {
+		block, blocks int
+		secret        []byte
+	}
+	count, skip := 0, 0
+	if cp != nil {
+		skip = cp.Done
+	}
+	next := func() ([]Part, bool, error) {
+		for {
+			var lines [][]byte
+			for _, scanner := range scanners {
+				if scanner.Scan() {
+					line := scanner.Bytes()
+					lines = append(lines, line)
+				}
+				if err := scanner.Err(); err != nil {
+					return nil, false, err
+				}
 			}
-			if err = scanner.Err(); err != nil {
-				return err
+			if len(lines) != size {
+				return nil, false, nil
 			}
-		}
-		if len(lines) != size {
-			break
-		}
-		if len(lines[0]) == 0 {
-			continue
-		}
-		for i, line := range lines {
-			if err := json.Unmarshal(line, &parts[i]); err != nil {
-				return err
+			if len(lines[0]) == 0 {
+				continue
 			}
-		}
-		threshold := parts[0].Threshold
-		if len(parts) < int(threshold) {
-			return errors.New(fmt.Sprintf("need %d input files", threshold))
-		}
-		block := parts[0].Block
-		blocks := parts[0].Blocks
-		if block != count+1 {
-			return errors.New("block mismatch")
-		}
-		var secret []byte
-		if secret, err = Combine(parts); err != nil {
-			return err
-		}
-		expect := parts[0].Digest
-		actual := utils.ComputeDigest(secret)
-		if expect != actual {
-			fmt.Printf("Expect secret digest: %s\n", expect)
-			fmt.Printf("Actual secret digest: %s\n", actual)
-			return errors.New("secret digest mismatch")
-		}
-		if count == 0 && verbose {
-			fmt.Printf("Blocks count: %d\n", blocks)
-		}
-		if count == 0 && output != nil {
-			var stat os.FileInfo
-			if stat, err = output.Stat(); err != nil {
-				return err
+			parts := make([]Part, size)
+			for i, line := range lines {
+				if err := json.Unmarshal(line, &parts[i]); err != nil {
+					return nil, false, err
+				}
+			}
+			threshold := parts[0].Threshold
+			if totalWeight(parts) < int(threshold) {
+				return nil, false, errors.New(fmt.Sprintf("need %d input files", threshold))
+			}
+			if parts[0].Block != count+1 {
+				return nil, false, errors.New("block mismatch")
 			}
-			if stat.Size() > 0 {
-				if truncate {
-					if err = output.Truncate(0); err != nil {
-						return err
+			count++
+			if count == 1 {
+				if cp != nil && resume && (cp.Set != parts[0].Set || cp.Blocks != parts[0].Blocks) {
+					return nil, false, fmt.Errorf("checkpoint %s does not match the input files", cp.path)
+				}
+				if cp != nil {
+					cp.Set, cp.Blocks = parts[0].Set, parts[0].Blocks
+				}
+				if verbose {
+					fmt.Printf("Blocks count: %d\n", parts[0].Blocks)
+					if len(parts[0].Weights) > 0 {
+						for i, p := range parts {
+							fmt.Printf("Part %d in file %d counts %d of threshold %d\n", p.Part, i+1, p.Weight(), threshold)
+						}
 					}
-					fmt.Printf("Truncate output file: %s\n", out)
-				} else {
-					return errors.New("output file is not empty")
 				}
 			}
-			if _, err = output.Write(secret); err != nil {
+			if count <= skip {
+				continue
+			}
+			return parts, true, nil
+		}
+	}
+	work := func(parts []Part) (combined, error) {
+		if err := verifyDealerParts(parts, in); err != nil {
+			return combined{}, err
+		}
+		secret, err := combineParts(parts, in, robust)
+		return combined{parts[0].Block, parts[0].Blocks, secret}, err
+	}
+	write := func(c combined) error {
+		defer utils.Wipe(c.secret)
+		if output != nil {
+			if _, err := output.Write(c.secret); err != nil {
+				return err
+			}
+			if err := cp.update([]*os.File{output}); err != nil {
 				return err
 			}
 		}
-		count++
 		if verbose {
-			l := len(secret)
-			w := len(fmt.Sprintf("%d", blocks))
+			l := len(c.secret)
+			w := len(fmt.Sprintf("%d", c.blocks))
 			if output != nil {
-				fmt.Printf("Block %*d/%d OK -- recovered %d bytes and appended them into %s\n", w, block, blocks, l, out)
+				fmt.Printf("Block %*d/%d OK -- recovered %d bytes and appended them into %s\n", w, c.block, c.blocks, l, out)
 			} else {
-				fmt.Printf("Block %*d/%d OK -- recovered %d bytes\n", w, block, blocks, l)
+				fmt.Printf("Block %*d/%d OK -- recovered %d bytes\n", w, c.block, c.blocks, l)
 			}
 		}
+		return nil
+	}
+	discard := func(c combined) { utils.Wipe(c.secret) }
+	if err := inOrder(next, work, write, discard); err != nil {
+		return err
+	}
+	if cp != nil {
+		return cp.remove()
 	}
 	return nil
 }
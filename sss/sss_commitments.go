@@ -0,0 +1,130 @@
+package sss
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/wangkang/fortify/files"
+	"github.com/wangkang/fortify/shamir"
+)
+
+// Commitments are the Feldman commitments of a secret share set, published alongside its parts.
+type Commitments struct {
+	Set         string   `json:"set"`
+	Threshold   uint8    `json:"threshold"`
+	Commitments []string `json:"commitments"`
+}
+
+var (
+	published     *Commitments
+	publishedLock sync.RWMutex
+)
+
+// SetCommitments sets the published commitments of a verifiable set. Verifiable parts read for
+// combining are then checked against them instead of the commitments they carry, which forged
+// parts could bring along, and parts which are not verifiable are rejected.
+func SetCommitments(c *Commitments) {
+	publishedLock.Lock()
+	defer publishedLock.Unlock()
+	published = c
+}
+
+func publishedCommitments() *Commitments {
+	publishedLock.RLock()
+	defer publishedLock.RUnlock()
+	return published
+}
+
+// checkVerifiable refuses parts which cannot be checked against the published commitments, if set.
+func checkVerifiable(parts []Part) error {
+	if publishedCommitments() == nil {
+		return nil
+	}
+	for _, p := range parts {
+		if p.Scheme != SchemeFeldman {
+			return errors.New("secret shares are not verifiable, and cannot be checked against the published commitments")
+		}
+	}
+	return nil
+}
+
+// WriteCommitmentsFile publishes the commitments of verifiable parts into a separate file.
+func WriteCommitmentsFile(ps []Part, path string, truncate bool) error {
+	if len(ps) == 0 || ps[0].Scheme != SchemeFeldman {
+		return errors.New("secret shares are not verifiable")
+	}
+	content, err := json.Marshal(&Commitments{
+		Set:         ps[0].Set,
+		Threshold:   ps[0].Threshold,
+		Commitments: ps[0].Commitments,
+	})
+	if err != nil {
+		return err
+	}
+	file, closer, err := files.OpenOutputFile(path, truncate)
+	if err != nil {
+		return err
+	}
+	defer closer()
+	_, err = file.Write(content)
+	return err
+}
+
+func ReadCommitmentsFile(path string) (*Commitments, error) {
+	file, closer, err := files.OpenInputFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closer()
+	var b []byte
+	if b, err = io.ReadAll(file); err != nil {
+		return nil, err
+	}
+	c := &Commitments{}
+	if err = json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("not a valid commitments file\nCaused by: %v", err)
+	}
+	return c, nil
+}
+
+// VerifyPart checks a verifiable part against the given commitments, or against the
+// commitments it carries itself if none are given.
+func VerifyPart(p Part, c *Commitments) error {
+	if p.Scheme != SchemeFeldman {
+		return errors.New("secret share is not verifiable")
+	}
+	commitments := p.Commitments
+	if c != nil {
+		if len(c.Set) > 0 && len(p.Set) > 0 && c.Set != p.Set {
+			return fmt.Errorf("secret share belongs to set %s, not %s", p.Set, c.Set)
+		}
+		commitments = c.Commitments
+	}
+	if len(commitments) != int(p.Threshold) {
+		return fmt.Errorf("expect %d commitments, got %d", p.Threshold, len(commitments))
+	}
+	share, err := base64.URLEncoding.DecodeString(p.Payload)
+	if err != nil {
+		return err
+	}
+	var cs [][]byte
+	if cs, err = decodeCommitments(commitments); err != nil {
+		return err
+	}
+	return shamir.VerifyShare(share, cs)
+}
+
+func decodeCommitments(encoded []string) ([][]byte, error) {
+	commitments := make([][]byte, len(encoded))
+	for i, c := range encoded {
+		var err error
+		if commitments[i], err = base64.URLEncoding.DecodeString(c); err != nil {
+			return nil, fmt.Errorf("invalid commitment %d: %v", i, err)
+		}
+	}
+	return commitments, nil
+}
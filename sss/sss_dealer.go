@@ -0,0 +1,196 @@
+package sss
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/wangkang/fortify/utils"
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	signingKey *utils.SecureBuffer
+	dealerKey  ed25519.PublicKey
+	dealerLock sync.RWMutex
+)
+
+// SetSigningKey sets the ed25519 key of the dealer, with which every part written by AppendParts is
+// signed, so that holders of the dealer public key can tell the parts from forged ones. The seed of
+// the key is kept in a secure buffer, and the key given is wiped, as is the previous seed; a nil key
+// only wipes the previous seed.
+func SetSigningKey(key ed25519.PrivateKey) {
+	dealerLock.Lock()
+	defer dealerLock.Unlock()
+	signingKey.Destroy()
+	signingKey = nil
+	if key != nil {
+		signingKey = utils.NewSecureBufferFrom(key.Seed())
+		utils.Wipe(key)
+	}
+}
+
+// SetDealerKey sets the ed25519 public key of the trusted dealer. Every part read for combining must
+// then carry a valid signature of the dealer, and unsigned or mis-signed parts are rejected before
+// any reconstruction.
+func SetDealerKey(key ed25519.PublicKey) {
+	dealerLock.Lock()
+	defer dealerLock.Unlock()
+	dealerKey = key
+}
+
+// ParseSigningKey parses an ed25519 private key in OpenSSH or PEM format, reading its passphrase
+// if it is protected by one.
+func ParseSigningKey(content []byte) (ed25519.PrivateKey, error) {
+	k, err := ssh.ParseRawPrivateKey(content)
+	var passphraseMissingError *ssh.PassphraseMissingError
+	if errors.As(err, &passphraseMissingError) {
+		var passphrase []byte
+		if passphrase, err = utils.ReadPassphrase("Enter passphrase of the dealer key: "); err != nil {
+			return nil, err
+		}
+		k, err = ssh.ParseRawPrivateKeyWithPassphrase(content, passphrase)
+		utils.Wipe(passphrase)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid dealer key: %v", err)
+	}
+	switch key := k.(type) {
+	case ed25519.PrivateKey:
+		return key, nil
+	case *ed25519.PrivateKey:
+		return *key, nil
+	default:
+		return nil, fmt.Errorf("dealer key requiring ed25519.PrivateKey, not %v", reflect.TypeOf(k))
+	}
+}
+
+// ParseDealerKey parses an ed25519 public key in authorized_keys format, as in id_ed25519.pub.
+func ParseDealerKey(content []byte) (ed25519.PublicKey, error) {
+	parsed, _, _, _, err := ssh.ParseAuthorizedKey(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dealer public key: %v", err)
+	}
+	if k, ok := parsed.(ssh.CryptoPublicKey); ok {
+		if key, ok := k.CryptoPublicKey().(ed25519.PublicKey); ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("dealer public key requiring ssh-ed25519, not %s", parsed.Type())
+}
+
+// dealerFingerprint names a dealer public key by its SHA256 fingerprint, as ssh-keygen -l does.
+func dealerFingerprint(key ed25519.PublicKey) string {
+	k, err := ssh.NewPublicKey(key)
+	if err != nil {
+		return ""
+	}
+	return ssh.FingerprintSHA256(k)
+}
+
+// signPart signs the part with the signing key, if one is set. The key is expanded from its seed into
+// ordinary memory for each signature, and wiped after it; ed25519.Sign does not accept locked memory,
+// and the copies it keeps of the expanded key are not covered.
+func signPart(p *Part) {
+	dealerLock.RLock()
+	defer dealerLock.RUnlock()
+	if signingKey == nil {
+		return
+	}
+	key := ed25519.NewKeyFromSeed(signingKey.Bytes())
+	defer utils.Wipe(key)
+	p.Dealer = dealerFingerprint(key.Public().(ed25519.PublicKey))
+	p.Signature = base64.URLEncoding.EncodeToString(ed25519.Sign(key, dealerMessage(*p)))
+}
+
+// verifyDealer checks the signature of the trusted dealer on the part, if a dealer key is set.
+func verifyDealer(p Part, name string) error {
+	dealerLock.RLock()
+	key := dealerKey
+	dealerLock.RUnlock()
+	if key == nil {
+		return nil
+	}
+	if len(p.Signature) == 0 {
+		return fmt.Errorf("secret share in file %s is not signed by the dealer", name)
+	}
+	signature, err := base64.URLEncoding.DecodeString(p.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid dealer signature of secret share in file %s", name)
+	}
+	if !ed25519.Verify(key, dealerMessage(p), signature) {
+		if fingerprint := dealerFingerprint(key); p.Dealer != fingerprint {
+			return fmt.Errorf("secret share in file %s is signed by dealer %s, not the trusted dealer %s",
+				name, p.Dealer, fingerprint)
+		}
+		return fmt.Errorf("dealer signature of secret share in file %s does not verify", name)
+	}
+	return nil
+}
+
+// verifyDealerParts checks the signature of the trusted dealer on every part of a block.
+func verifyDealerParts(parts []Part, names []string) error {
+	for i, p := range parts {
+		if err := verifyDealer(p, names[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dealerMessage is what the dealer signs: every field of the part but the signature and the
+// timestamp, each prefixed by its length, in a fixed order which does not depend on the JSON
+// encoding of the part.
+func dealerMessage(p Part) []byte {
+	var b bytes.Buffer
+	b.WriteString("fortify sss dealer\x00")
+	field := func(s string) { _, _ = fmt.Fprintf(&b, "%d:%s", len(s), s) }
+	list := func(l []string) {
+		field(strconv.Itoa(len(l)))
+		for _, s := range l {
+			field(s)
+		}
+	}
+	number := func(n int) { field(strconv.Itoa(n)) }
+	field(p.Dealer)
+	field(p.Payload)
+	number(p.Block)
+	number(p.Blocks)
+	number(p.Part)
+	number(int(p.Parts))
+	number(int(p.Threshold))
+	field(p.Digest)
+	number(p.Blinding)
+	field(p.Set)
+	field(p.SetMac)
+	field(p.Coordinates)
+	field(p.Scheme)
+	list(p.Commitments)
+	list(p.Points)
+	field(p.Weights)
+	number(p.Group)
+	field(p.Groups)
+	number(int(p.GroupThreshold))
+	field(p.Key)
+	number(p.Size)
+	field(strconv.FormatBool(p.Diffusion))
+	if s := p.Seal; s != nil {
+		list([]string{s.Kdf, s.Salt, strconv.Itoa(int(s.Time)), strconv.Itoa(int(s.Memory)), strconv.Itoa(int(s.Threads))})
+	} else {
+		list(nil)
+	}
+	list(p.Labels)
+	field(p.Holder)
+	if p.NotAfter != nil {
+		field(p.NotAfter.UTC().Format(time.RFC3339Nano))
+	} else {
+		field("")
+	}
+	return b.Bytes()
+}
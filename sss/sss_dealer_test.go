@@ -0,0 +1,95 @@
+package sss
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestVerifyDealer(t *testing.T) {
+	t.Cleanup(func() {
+		SetSigningKey(nil)
+		SetDealerKey(nil)
+	})
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	key := slices.Clone(private)
+	SetSigningKey(key)
+	if !slices.Equal(key, make([]byte, len(key))) {
+		t.Fatalf("expect the signing key wiped once set")
+	}
+	SetDealerKey(public)
+	ps, err := Split([]byte("test"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	notAfter := time.Now().Add(time.Hour)
+	p := ps[0]
+	p.Holder, p.Labels, p.NotAfter = "Alice", []string{"prod"}, &notAfter
+	signPart(&p)
+	if err = verifyDealer(p, "part"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The signature survives the JSON encoding of the part, and a changed timestamp.
+	content, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var q Part
+	if err = json.Unmarshal(content, &q); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	q.Timestamp = q.Timestamp.Add(time.Minute)
+	if err = verifyDealer(q, "part"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for name, change := range map[string]func(*Part){
+		"payload":   func(p *Part) { p.Payload = ps[1].Payload },
+		"part":      func(p *Part) { p.Part = 2 },
+		"threshold": func(p *Part) { p.Threshold = 1 },
+		"set":       func(p *Part) { p.Set = "0123456789abcdef" },
+		"holder":    func(p *Part) { p.Holder = "Mallory" },
+		"labels":    func(p *Part) { p.Labels = []string{"prod", "test"} },
+		"not after": func(p *Part) { later := notAfter.Add(time.Hour); p.NotAfter = &later },
+		"seal":      func(p *Part) { p.Seal = &Seal{Kdf: KdfArgon2id} },
+		"unsigned":  func(p *Part) { p.Signature = "" },
+		"signature": func(p *Part) { p.Signature = ps[1].Payload },
+	} {
+		forged := p
+		change(&forged)
+		if err = verifyDealer(forged, "part"); err == nil {
+			t.Fatalf("%s: expect error", name)
+		}
+	}
+
+	// Signed by another dealer
+	other, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	SetDealerKey(other)
+	if err = verifyDealer(p, "part"); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestDealerMessage(t *testing.T) {
+	// Fields are delimited by their lengths, so that moving bytes between them changes the message.
+	a := Part{Holder: "ab", Set: "c"}
+	b := Part{Holder: "a", Set: "bc"}
+	if slices.Equal(dealerMessage(a), dealerMessage(b)) {
+		t.Fatalf("expect different messages")
+	}
+	c := Part{Labels: []string{"a", "b"}}
+	d := Part{Labels: []string{"a"}, Holder: "b"}
+	if slices.Equal(dealerMessage(c), dealerMessage(d)) {
+		t.Fatalf("expect different messages")
+	}
+}
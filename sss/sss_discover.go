@@ -0,0 +1,391 @@
+package sss
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// candidate is a share file found by DiscoverKeyParts, along with the part of its first block.
+type candidate struct {
+	path     string
+	part     Part
+	explicit bool
+}
+
+// DiscoverKeyParts resolves the leading arguments naming share files, up to the first one that is
+// neither a file, a directory nor a glob pattern matching files, and returns the paths of the
+// share files to combine along with the number of arguments consumed.
+//
+// Arguments naming files are used unless the digest or set ID given is of another set. The share
+// files found in directories and by glob patterns are grouped by set, and only files of one set are
+// used: the set of the digest or set ID if given, else the set of the files named explicitly, else
+// the only set with enough shares. Of these, only as many as needed to meet the threshold are used,
+// or all of them if all is true. If the threshold cannot be met, the error tells how many more
+// shares are needed.
+func DiscoverKeyParts(args []string, digest, set string, all, verbose bool) (paths []string, consumed int, err error) {
+	var candidates []candidate
+	discovered := false
+	for _, arg := range args {
+		var found []string
+		if found, err = expandKeyPartArg(arg); err != nil {
+			return nil, 0, err
+		}
+		if found == nil {
+			if !isKeyPartArg(arg) {
+				break
+			}
+			candidates = append(candidates, candidate{path: arg, explicit: true})
+		} else {
+			discovered = true
+			for _, path := range found {
+				candidates = append(candidates, candidate{path: path})
+			}
+		}
+		consumed++
+	}
+	if !discovered {
+		return args[:consumed], consumed, nil
+	}
+	// Read the discovered files, and the explicit ones which tell the set to use.
+	var readable []candidate
+	for _, c := range candidates {
+		if c.explicit && c.path == "-" {
+			readable = append(readable, c)
+			continue
+		}
+		if c.part, err = readCandidate(c.path); err != nil {
+			if c.explicit {
+				return nil, 0, err
+			}
+			if verbose {
+				fmt.Printf("Skip %s: %v\n", c.path, err)
+			}
+			continue
+		}
+		readable = append(readable, c)
+	}
+	var selected []candidate
+	if selected, err = selectCandidates(readable, digest, set, all); err != nil {
+		return nil, 0, err
+	}
+	for _, c := range selected {
+		paths = append(paths, c.path)
+		if verbose && !c.explicit {
+			fmt.Printf("Use %s (%s)\n", c.path, describeCandidate(c.part))
+		}
+	}
+	return paths, consumed, nil
+}
+
+// expandKeyPartArg returns the regular files in a directory, or matching a glob pattern, or nil if
+// the argument is neither.
+func expandKeyPartArg(arg string) ([]string, error) {
+	if stat, err := os.Stat(arg); err == nil {
+		if !stat.IsDir() {
+			return nil, nil
+		}
+		entries, err := os.ReadDir(arg)
+		if err != nil {
+			return nil, err
+		}
+		found := []string{}
+		for _, e := range entries {
+			if e.Type().IsRegular() {
+				found = append(found, filepath.Join(arg, e.Name()))
+			}
+		}
+		return found, nil
+	}
+	if !strings.ContainsAny(arg, "*?[") {
+		return nil, nil
+	}
+	matches, err := filepath.Glob(arg)
+	if err != nil {
+		return nil, nil
+	}
+	var found []string
+	for _, m := range matches {
+		if stat, err := os.Stat(m); err == nil && stat.Mode().IsRegular() {
+			found = append(found, m)
+		}
+	}
+	return found, nil
+}
+
+// isKeyPartArg reports whether the argument names a key part as CombineKeyFiles reads it.
+func isKeyPartArg(arg string) bool {
+	if arg == "-" {
+		return true
+	}
+	stat, err := os.Stat(arg)
+	return err == nil && stat.Mode().IsRegular()
+}
+
+// readCandidate reads the part of the first block of a share file.
+func readCandidate(path string) (Part, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Part{}, err
+	}
+	defer func() { _ = file.Close() }()
+	reader := bufio.NewReaderSize(file, 4096)
+	var head []byte
+	if head, err = reader.Peek(1); err != nil {
+		return Part{}, fmt.Errorf("not a valid sss key part: %s", path)
+	}
+	if head[0] == '{' {
+		// Share files of several blocks hold one part per line.
+		line, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return Part{}, err
+		}
+		p, err := parseKeyPart(path, bytes.TrimSpace(line))
+		if err == nil && len(p.Payload) == 0 {
+			err = fmt.Errorf("not a valid sss key part: %s", path)
+		}
+		return p, err
+	}
+	content, err := io.ReadAll(io.LimitReader(reader, 64*1024))
+	if err != nil {
+		return Part{}, err
+	}
+	if !utf8.Valid(content) {
+		return Part{}, fmt.Errorf("not a valid sss key part: %s", path)
+	}
+	return parseKeyPart(path, content)
+}
+
+// setKey identifies the set of a part, by its set ID, or by its digest for parts without one.
+func setKey(p Part) string {
+	if len(p.Set) > 0 {
+		return p.Set
+	}
+	return "digest:" + p.Digest
+}
+
+func describeCandidate(p Part) string {
+	s := fmt.Sprintf("part %d", p.Part)
+	if len(p.Groups) > 0 {
+		s = fmt.Sprintf("part %d of group %d", p.Part, p.Group)
+	}
+	s += " of set " + setKey(p)
+	if len(p.Holder) > 0 {
+		s += ", held by " + p.Holder
+	}
+	return s
+}
+
+// selectCandidates picks the set to combine, and all or the fewest of its candidates meeting the threshold.
+func selectCandidates(candidates []candidate, digest, set string, all bool) ([]candidate, error) {
+	var explicit []candidate
+	for _, c := range candidates {
+		if c.explicit {
+			explicit = append(explicit, c)
+		}
+	}
+	match := func(p Part) bool { return true }
+	switch {
+	case len(digest) > 0:
+		match = func(p Part) bool { return matchDigest(p, digest) }
+	case len(set) > 0:
+		match = func(p Part) bool { return p.Set == set }
+	case len(explicit) > 0 && len(explicit[0].part.Payload) > 0:
+		key := setKey(explicit[0].part)
+		match = func(p Part) bool { return setKey(p) == key }
+	}
+	// Group the matching candidates by set, with explicit candidates first, and each part only once.
+	var keys []string
+	sets := make(map[string][]candidate)
+	seen := make(map[string]bool)
+	ordered := slices.Clone(explicit)
+	for _, c := range candidates {
+		if !c.explicit && match(c.part) {
+			ordered = append(ordered, c)
+		}
+	}
+	for _, c := range ordered {
+		key := setKey(c.part)
+		id := fmt.Sprintf("%s/%d/%d", key, c.part.Group, c.part.Part)
+		if len(c.part.Payload) > 0 {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+		}
+		if _, ok := sets[key]; !ok {
+			keys = append(keys, key)
+		}
+		sets[key] = append(sets[key], c)
+	}
+	var complete []string
+	var shortfalls []string
+	for _, key := range keys {
+		if shortfall := describeShortfall(sets[key]); len(shortfall) > 0 {
+			shortfalls = append(shortfalls, fmt.Sprintf("set %s: %s", key, shortfall))
+		} else {
+			complete = append(complete, key)
+		}
+	}
+	switch {
+	case len(complete) == 0 && len(shortfalls) == 0:
+		return nil, errors.New("no share files found of the required set")
+	case len(complete) == 0:
+		return nil, fmt.Errorf("not enough share files found\n%s", strings.Join(shortfalls, "\n"))
+	case len(complete) > 1 && len(digest) == 0 && len(set) == 0 && len(explicit) == 0:
+		return nil, fmt.Errorf("share files of %d sets found, name one of them explicitly: %s",
+			len(complete), strings.Join(complete, ", "))
+	}
+	// The explicit candidates come first, but a set matching the digest or set ID is preferred to theirs.
+	chosen := ""
+	for _, key := range complete {
+		if p := sets[key][0].part; len(p.Payload) == 0 || match(p) {
+			chosen = key
+			break
+		}
+	}
+	if len(chosen) == 0 {
+		return nil, fmt.Errorf("no share files found of the required set, the files found are of the sets: %s",
+			strings.Join(complete, ", "))
+	}
+	if all {
+		return sets[chosen], nil
+	}
+	return fewestCandidates(sets[chosen]), nil
+}
+
+// matchDigest reports whether a part is of the secret of the digest, by the first bytes of the digest
+// carried by the words of parts without the whole digest.
+func matchDigest(p Part, digest string) bool {
+	if len(p.Digest) > 0 || len(p.digestCheck) == 0 {
+		return p.Digest == digest
+	}
+	decoded, err := base64.URLEncoding.DecodeString(digest)
+	return err == nil && bytes.HasPrefix(decoded, p.digestCheck)
+}
+
+// describeShortfall tells how many more shares the candidates of one set need, or returns an
+// empty string if they meet the threshold.
+func describeShortfall(cs []candidate) string {
+	first := cs[0].part
+	if len(first.Payload) == 0 {
+		return ""
+	}
+	if len(first.Groups) == 0 {
+		weight := 0
+		for _, c := range cs {
+			weight += c.part.Weight()
+		}
+		if weight >= int(first.Threshold) {
+			return ""
+		}
+		return fmt.Sprintf("found %d of the %d shares needed, %d more needed",
+			weight, first.Threshold, int(first.Threshold)-weight)
+	}
+	groups, err := ParseGroups(first.Groups)
+	if err != nil {
+		return err.Error()
+	}
+	members := make([]int, len(groups))
+	for _, c := range cs {
+		if g := c.part.Group; g >= 1 && g <= len(groups) {
+			members[g-1]++
+		}
+	}
+	satisfied := 0
+	var missing []int
+	var details []string
+	for i, g := range groups {
+		if members[i] >= int(g.Threshold) {
+			satisfied++
+		} else {
+			missing = append(missing, int(g.Threshold)-members[i])
+			details = append(details, fmt.Sprintf("group %d (%s) has %d, %d more needed",
+				i+1, g, members[i], int(g.Threshold)-members[i]))
+		}
+	}
+	if satisfied >= int(first.GroupThreshold) {
+		return ""
+	}
+	slices.Sort(missing)
+	more := 0
+	for _, i := range missing[:int(first.GroupThreshold)-satisfied] {
+		more += i
+	}
+	return fmt.Sprintf("%d of the %d groups needed are complete, at least %d more shares needed; %s",
+		satisfied, first.GroupThreshold, more, strings.Join(details, "; "))
+}
+
+// fewestCandidates keeps the explicit candidates, and adds the fewest others meeting the threshold.
+func fewestCandidates(cs []candidate) []candidate {
+	first := cs[0].part
+	var out, rest []candidate
+	for _, c := range cs {
+		if c.explicit {
+			out = append(out, c)
+		} else {
+			rest = append(rest, c)
+		}
+	}
+	if len(first.Payload) == 0 {
+		// Parts read from the standard input are unknown yet.
+		return cs
+	}
+	if len(first.Groups) == 0 {
+		slices.SortStableFunc(rest, func(a, b candidate) int { return b.part.Weight() - a.part.Weight() })
+		weight := 0
+		for _, c := range out {
+			weight += c.part.Weight()
+		}
+		for _, c := range rest {
+			if weight >= int(first.Threshold) {
+				break
+			}
+			out = append(out, c)
+			weight += c.part.Weight()
+		}
+		return out
+	}
+	groups, _ := ParseGroups(first.Groups)
+	members := make(map[int]int)
+	for _, c := range out {
+		members[c.part.Group]++
+	}
+	satisfied := func() (n int) {
+		for i, g := range groups {
+			if members[i+1] >= int(g.Threshold) {
+				n++
+			}
+		}
+		return
+	}
+	// Complete the groups closest to their threshold first.
+	slices.SortStableFunc(rest, func(a, b candidate) int {
+		return needed(groups, members, a.part.Group) - needed(groups, members, b.part.Group)
+	})
+	for _, c := range rest {
+		if satisfied() >= int(first.GroupThreshold) {
+			break
+		}
+		if g := c.part.Group; g >= 1 && g <= len(groups) && members[g] < int(groups[g-1].Threshold) {
+			out = append(out, c)
+			members[g]++
+		}
+	}
+	return out
+}
+
+func needed(groups []Group, members map[int]int, group int) int {
+	if group < 1 || group > len(groups) {
+		return 256
+	}
+	return int(groups[group-1].Threshold) - members[group]
+}
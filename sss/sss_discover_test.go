@@ -0,0 +1,165 @@
+package sss
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestParts(t *testing.T, ps []Part, prefix string) {
+	t.Helper()
+	if err := AppendParts(ps, 0, 1, prefix, true); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	CloseAllFilesForWrite()
+}
+
+func TestDiscoverKeyParts(t *testing.T) {
+	dir := t.TempDir()
+	a, err := Split([]byte("test a"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	b, err := Split([]byte("test b"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	writeTestParts(t, a, filepath.Join(dir, "a"))
+	writeTestParts(t, b, filepath.Join(dir, "b"))
+	if err = os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a share\n"), 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Two complete sets, and nothing to tell which one
+	if _, _, err = DiscoverKeyParts([]string{dir}, "", "", false, false); err == nil {
+		t.Fatalf("expect error")
+	}
+
+	// The fewest files of the set of the digest, leaving the following arguments
+	paths, consumed, err := DiscoverKeyParts([]string{dir, "output"}, a[0].Digest, "", false, false)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if consumed != 1 || len(paths) != 2 {
+		t.Fatalf("expect 2 paths of 1 argument, actual %v of %d", paths, consumed)
+	}
+	for _, path := range paths {
+		if !strings.HasPrefix(filepath.Base(path), "a") {
+			t.Fatalf("expect files of set a, actual %v", paths)
+		}
+	}
+
+	// All the files of the set ID
+	if paths, _, err = DiscoverKeyParts([]string{filepath.Join(dir, "*.json")}, "", b[0].Set, true, false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(paths) != 3 || !strings.HasPrefix(filepath.Base(paths[0]), "b") {
+		t.Fatalf("expect 3 files of set b, actual %v", paths)
+	}
+
+	// The set of the file named explicitly
+	explicit := filepath.Join(dir, "b2of3.json")
+	if paths, _, err = DiscoverKeyParts([]string{explicit, dir}, "", "", false, false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(paths) != 2 || paths[0] != explicit || !strings.HasPrefix(filepath.Base(paths[1]), "b") {
+		t.Fatalf("expect %s and a file of set b, actual %v", explicit, paths)
+	}
+
+	// Files of the digest are preferred to a complete set named explicitly
+	args := []string{filepath.Join(dir, "b1of3.json"), explicit, dir}
+	if paths, _, err = DiscoverKeyParts(args, a[0].Digest, "", false, false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, path := range paths {
+		if !strings.HasPrefix(filepath.Base(path), "a") {
+			t.Fatalf("expect files of set a, actual %v", paths)
+		}
+	}
+
+	// Only named files, not discovered
+	args = []string{explicit, "output"}
+	if paths, consumed, err = DiscoverKeyParts(args, "", "", false, false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if consumed != 1 || len(paths) != 1 || paths[0] != explicit {
+		t.Fatalf("expect %s, actual %v of %d", explicit, paths, consumed)
+	}
+}
+
+func TestDiscoverKeyParts_shortfall(t *testing.T) {
+	dir := t.TempDir()
+	ps, err := Split([]byte("test"), 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	writeTestParts(t, ps[:2], filepath.Join(dir, "a"))
+	_, _, err = DiscoverKeyParts([]string{dir}, ps[0].Digest, "", false, false)
+	if err == nil {
+		t.Fatalf("expect error")
+	}
+	if !strings.Contains(err.Error(), "found 2 of the 3 shares needed, 1 more needed") {
+		t.Fatalf("err: %v", err)
+	}
+
+	// A duplicated part counts once
+	writeTestParts(t, ps[1:2], filepath.Join(dir, "copy"))
+	if _, _, err = DiscoverKeyParts([]string{dir}, ps[0].Digest, "", false, false); err == nil {
+		t.Fatalf("expect error")
+	}
+
+	// Nothing of the digest
+	if _, _, err = DiscoverKeyParts([]string{dir}, "AAAA", "", false, false); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestDiscoverKeyParts_words(t *testing.T) {
+	dir := t.TempDir()
+	a, err := Split([]byte("test a"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	b, err := Split([]byte("test b"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, p := range append(a, b...) {
+		name := "a"
+		if p.Set == b[0].Set {
+			name = "b"
+		}
+		path := filepath.Join(dir, fmt.Sprintf("%s%dof%d.txt", name, p.Part, p.Parts))
+		if err = WriteWordsFile(p, path, true); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	CloseAllFilesForWrite()
+
+	// Words carry the first bytes of the digest, not the whole of it.
+	paths, _, err := DiscoverKeyParts([]string{dir}, b[0].Digest, "", false, false)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("expect 2 paths, actual %v", paths)
+	}
+	for _, path := range paths {
+		if !strings.HasPrefix(filepath.Base(path), "b") {
+			t.Fatalf("expect files of set b, actual %v", paths)
+		}
+	}
+	parts, err := CombineKeyFiles(paths)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	secret, err := Combine(parts)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(secret) != "test b" {
+		t.Fatalf("expect %q, actual %q", "test b", secret)
+	}
+}
@@ -0,0 +1,135 @@
+package sss
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/wangkang/fortify/shamir"
+	"github.com/wangkang/fortify/utils"
+)
+
+// Extend issues an additional part for the secret share set of the given parts, which must
+// number at least the threshold. The new part gets the number part, or the next number after
+// the known parts if part is zero. Its x coordinate is picked at random among those unused by
+// every part of the set, as recorded by the most recently issued part, which must be given.
+func Extend(parts []Part, part int) (Part, error) {
+	if len(parts) == 0 {
+		return Part{}, errors.New("no secret shares to extend")
+	}
+	first := parts[0]
+	if err := unsupported(parts, "extending", kindVerifiable, kindWeighted); err != nil {
+		return Part{}, err
+	}
+	if totalWeight(parts) < int(first.Threshold) {
+		return Part{}, fmt.Errorf("need %d secret shares, got %d", first.Threshold, totalWeight(parts))
+	}
+	shares, err := decodeShares(parts)
+	if err != nil {
+		return Part{}, err
+	}
+	var secret []byte
+	if secret, err = combineShares(parts, shares); err != nil {
+		return Part{}, err
+	}
+	raw := secret
+	secret, err = unblind(parts, raw)
+	utils.Wipe(raw)
+	if err != nil {
+		return Part{}, err
+	}
+	utils.Wipe(secret)
+	used := make([]byte, 0, len(parts))
+	last, latest := 0, false
+	for i, p := range parts {
+		if p.Part == part {
+			return Part{}, fmt.Errorf("part %d already exists in file %d", part, i+1)
+		}
+		var recorded []byte
+		if recorded, err = hex.DecodeString(p.Coordinates); err != nil {
+			return Part{}, fmt.Errorf("invalid coordinates in file %d: %v", i+1, err)
+		}
+		used = append(used, recorded...)
+		last = max(last, p.Part, int(p.Parts))
+		// The part issued last records the coordinates of all parts before it.
+		latest = latest || p.Parts == MaxParts(parts) && len(recorded) >= int(p.Parts)
+	}
+	if !latest {
+		return Part{}, fmt.Errorf("the x coordinates of all %d parts of the set are not recorded by the given parts, "+
+			"give the most recently issued part to avoid handing out the share of an earlier extension again", MaxParts(parts))
+	}
+	for _, share := range shares {
+		used = append(used, share[len(share)-1])
+	}
+	if part == 0 {
+		part = last + 1
+	}
+	if part > 255 {
+		return Part{}, errors.New("parts cannot exceed 255")
+	}
+	var candidates []byte
+	for x := 1; x <= 255; x++ {
+		if !slices.Contains(used, byte(x)) {
+			candidates = append(candidates, byte(x))
+		}
+	}
+	if len(candidates) == 0 {
+		return Part{}, errors.New("no unused x coordinate left")
+	}
+	var share []byte
+	var x byte
+	if first.Scheme == SchemeFeldman {
+		// The x coordinates of verifiable parts are their numbers.
+		if x = byte(part); !slices.Contains(candidates, x) {
+			return Part{}, fmt.Errorf("x coordinate %d is already in use", x)
+		}
+		share, err = shamir.ExtendVerifiable(shares[:first.Threshold], x)
+	} else {
+		var i *big.Int
+		if i, err = rand.Int(rand.Reader, big.NewInt(int64(len(candidates)))); err != nil {
+			return Part{}, err
+		}
+		x = candidates[i.Int64()]
+		share, err = shamir.Extend(shares[:first.Threshold], x)
+	}
+	if err != nil {
+		return Part{}, err
+	}
+	weights := first.Weights
+	if n := strings.Count(weights, ",") + 1; len(weights) > 0 && part > n {
+		// Extended parts carry a single point.
+		weights += strings.Repeat(",1", part-n)
+	}
+	slices.Sort(used)
+	used = append(slices.Compact(used), x)
+	return Part{
+		Payload:     base64.URLEncoding.EncodeToString(share),
+		Part:        part,
+		Parts:       uint8(max(part, int(MaxParts(parts)))),
+		Threshold:   first.Threshold,
+		Digest:      first.Digest,
+		Blinding:    first.Blinding,
+		Set:         first.Set,
+		Coordinates: hex.EncodeToString(used),
+		Scheme:      first.Scheme,
+		Commitments: first.Commitments,
+		SetMac:      first.SetMac,
+		Weights:     weights,
+		Timestamp:   time.Now(),
+	}, nil
+}
+
+// MaxParts returns the largest number of parts recorded among the given parts. The parts of an
+// extended set record different numbers, as older parts do not know of the later ones.
+func MaxParts(parts []Part) (n uint8) {
+	for _, p := range parts {
+		n = max(n, p.Parts)
+	}
+	return
+}
@@ -0,0 +1,217 @@
+package sss
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/wangkang/fortify/shamir"
+	"github.com/wangkang/fortify/utils"
+)
+
+// Group describes one group of a group sharing, in which any Threshold of the Parts members
+// recover the share of the group.
+type Group struct {
+	Parts     uint8
+	Threshold uint8
+}
+
+func (g Group) String() string {
+	return fmt.Sprintf("%dof%d", g.Threshold, g.Parts)
+}
+
+// ParseGroups parses groups written as comma separated <threshold>of<parts>, e.g. "2of3,3of5".
+func ParseGroups(spec string) ([]Group, error) {
+	var groups []Group
+	for _, i := range strings.Split(spec, ",") {
+		var g Group
+		if n, err := fmt.Sscanf(strings.TrimSpace(i), "%dof%d", &g.Threshold, &g.Parts); err != nil || n != 2 ||
+			g.String() != strings.TrimSpace(i) {
+			return nil, fmt.Errorf("invalid group %q, expect <threshold>of<parts> such as 2of3", i)
+		}
+		if g.Threshold < 1 || g.Threshold > g.Parts {
+			return nil, fmt.Errorf("invalid group %q, threshold must be between 1 and the number of parts", i)
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+// FormatGroups writes groups in the form parsed by ParseGroups.
+func FormatGroups(groups []Group) string {
+	s := make([]string, len(groups))
+	for i, g := range groups {
+		s[i] = g.String()
+	}
+	return strings.Join(s, ",")
+}
+
+// GroupTotals returns the number of members of all groups, and the smallest number of members
+// which recover the secret, both capped at 255.
+func GroupTotals(groups []Group, groupThreshold uint8) (parts, threshold uint8) {
+	var p, t int
+	thresholds := make([]int, len(groups))
+	for i, g := range groups {
+		p += int(g.Parts)
+		thresholds[i] = int(g.Threshold)
+	}
+	slices.Sort(thresholds)
+	for _, i := range thresholds[:min(int(groupThreshold), len(thresholds))] {
+		t += i
+	}
+	return uint8(min(p, 255)), uint8(min(t, 255))
+}
+
+// SplitGroups splits the secret into shares of the groups, any groupThreshold of which recover
+// the secret, and splits the share of every group among its members. The parts are returned by group.
+func SplitGroups(secret []byte, groupThreshold uint8, groups []Group) ([][]Part, error) {
+	raw, digest, err := blind(secret)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.Wipe(raw)
+	return splitGroups(raw, digest, blindingSize, groupThreshold, groups)
+}
+
+func splitGroups(raw []byte, digest string, blinding int, groupThreshold uint8, groups []Group) ([][]Part, error) {
+	if len(groups) == 0 || len(groups) > 255 {
+		return nil, errors.New("number of groups must be between 1 and 255")
+	}
+	if groupThreshold < 1 || int(groupThreshold) > len(groups) {
+		return nil, errors.New("group threshold must be between 1 and the number of groups")
+	}
+	for i, g := range groups {
+		if g.Threshold < 1 || g.Threshold > g.Parts {
+			return nil, fmt.Errorf("invalid group %d: %s", i+1, g)
+		}
+	}
+	groupShares, err := splitThreshold(raw, len(groups), int(groupThreshold))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, i := range groupShares {
+			utils.Wipe(i)
+		}
+	}()
+	var set string
+	if set, err = newSetId(); err != nil {
+		return nil, err
+	}
+	spec := FormatGroups(groups)
+	key := setMacKey(raw)
+	out := make([][]Part, len(groups))
+	for i, g := range groups {
+		var shares [][]byte
+		if shares, err = splitThreshold(groupShares[i], int(g.Parts), int(g.Threshold)); err != nil {
+			return nil, err
+		}
+		coordinates := make([]byte, len(shares))
+		for index, share := range shares {
+			coordinates[index] = share[len(share)-1]
+		}
+		for index, share := range shares {
+			out[i] = append(out[i], Part{
+				Parts:          g.Parts,
+				Part:           index + 1,
+				Payload:        base64.URLEncoding.EncodeToString(share),
+				Timestamp:      time.Now(),
+				Threshold:      g.Threshold,
+				Digest:         digest,
+				Blinding:       blinding,
+				Set:            set,
+				Coordinates:    hex.EncodeToString(coordinates),
+				Group:          i + 1,
+				Groups:         spec,
+				GroupThreshold: groupThreshold,
+			})
+			out[i][index].SetMac = setMac(key, out[i][index])
+		}
+	}
+	return out, nil
+}
+
+// splitThreshold splits like shamir.Split, but also accepts a threshold of one, for which every
+// share is a copy of the secret tagged with its x coordinate.
+func splitThreshold(secret []byte, parts, threshold int) ([][]byte, error) {
+	if threshold != 1 {
+		return shamir.Split(secret, parts, threshold)
+	}
+	out := make([][]byte, parts)
+	for i := range out {
+		out[i] = append(slices.Clone(secret), byte(i+1))
+	}
+	return out, nil
+}
+
+// combineThreshold reverses splitThreshold.
+func combineThreshold(shares [][]byte, threshold uint8) ([]byte, error) {
+	if threshold != 1 {
+		return shamir.Combine(shares)
+	}
+	if len(shares) == 0 || len(shares[0]) < 2 {
+		return nil, errors.New("parts must be at least two bytes")
+	}
+	return slices.Clone(shares[0][:len(shares[0])-1]), nil
+}
+
+// combineGroups reconstructs the raw secret from the parts of a group sharing. If the parts do not
+// satisfy enough groups, the error tells which groups are missing members.
+func combineGroups(parts []Part) ([]byte, error) {
+	first := parts[0]
+	groups, err := ParseGroups(first.Groups)
+	if err != nil {
+		return nil, err
+	}
+	members := make([][]Part, len(groups))
+	for i, p := range parts {
+		if p.Groups != first.Groups || p.GroupThreshold != first.GroupThreshold {
+			return nil, fmt.Errorf("secret share groups mismatch in file %v: expect %d of %s, actual %d of %s",
+				i+1, first.GroupThreshold, first.Groups, p.GroupThreshold, p.Groups)
+		}
+		if p.Set != first.Set || p.Digest != first.Digest {
+			return nil, fmt.Errorf("secret share set mismatch in file %v: expect %s, actual %s", i+1, first.Set, p.Set)
+		}
+		if p.Group < 1 || p.Group > len(groups) {
+			return nil, fmt.Errorf("invalid secret share group %d in file %v", p.Group, i+1)
+		}
+		if g := groups[p.Group-1]; p.Threshold != g.Threshold || p.Parts > g.Parts {
+			return nil, fmt.Errorf("secret share in file %v does not match group %d (%s)", i+1, p.Group, g)
+		}
+		members[p.Group-1] = append(members[p.Group-1], p)
+	}
+	var (
+		shares  [][]byte
+		missing []string
+	)
+	defer func() {
+		for _, i := range shares {
+			utils.Wipe(i)
+		}
+	}()
+	for i, g := range groups {
+		if len(members[i]) < int(g.Threshold) {
+			missing = append(missing, fmt.Sprintf("group %d (%s) has %d of the %d members needed",
+				i+1, g, len(members[i]), g.Threshold))
+			continue
+		}
+		var decoded [][]byte
+		if decoded, err = decodeShares(members[i]); err != nil {
+			return nil, fmt.Errorf("group %d: %v", i+1, err)
+		}
+		var share []byte
+		if share, err = combineThreshold(decoded, g.Threshold); err != nil {
+			return nil, fmt.Errorf("group %d: %v", i+1, err)
+		}
+		shares = append(shares, share)
+	}
+	if len(shares) < int(first.GroupThreshold) {
+		return nil, fmt.Errorf("secret shares satisfy %d of the %d groups needed: %s",
+			len(shares), first.GroupThreshold, strings.Join(missing, "; "))
+	}
+	return combineThreshold(shares[:first.GroupThreshold], first.GroupThreshold)
+}
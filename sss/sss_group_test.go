@@ -0,0 +1,104 @@
+package sss
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSplitGroups(t *testing.T) {
+	secret := []byte("test groups")
+	groups, err := ParseGroups("2of3, 1of1,3of5")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if spec := FormatGroups(groups); spec != "2of3,1of1,3of5" {
+		t.Fatalf("expect 2of3,1of1,3of5, actual %s", spec)
+	}
+	gs, err := SplitGroups(secret, 2, groups)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(gs) != 3 || len(gs[0]) != 3 || len(gs[1]) != 1 || len(gs[2]) != 5 {
+		t.Fatalf("expect groups of 3, 1 and 5 parts, actual %d groups", len(gs))
+	}
+	for _, parts := range [][]Part{
+		{gs[0][0], gs[0][2], gs[1][0]},
+		{gs[2][4], gs[1][0], gs[2][0], gs[2][1]},
+		{gs[0][1], gs[2][2], gs[0][2], gs[2][3], gs[2][0]},
+		{gs[0][0], gs[0][1], gs[0][2], gs[1][0], gs[2][0], gs[2][1], gs[2][2]},
+	} {
+		recombined, err := Combine(parts)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !bytes.Equal(recombined, secret) {
+			t.Fatalf("expect %q, actual %q", secret, recombined)
+		}
+	}
+
+	// One complete group, and members short of the threshold of the others
+	_, err = Combine([]Part{gs[0][0], gs[0][1], gs[2][0], gs[2][1]})
+	if err == nil {
+		t.Fatalf("expect error")
+	}
+	if !strings.Contains(err.Error(), "group 3 (3of5) has 2 of the 3 members needed") {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Many members of one group count for that group only.
+	if _, err = Combine(gs[2]); err == nil {
+		t.Fatalf("expect error")
+	}
+
+	// A part claiming another group
+	forged := gs[1][0]
+	forged.Group = 4
+	if _, err = Combine([]Part{gs[0][0], gs[0][1], forged}); err == nil {
+		t.Fatalf("expect error")
+	}
+
+	// Parts of another group sharing
+	others, err := SplitGroups(secret, 2, groups)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err = Combine([]Part{gs[0][0], gs[0][1], others[1][0]}); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestSplitGroups_invalid(t *testing.T) {
+	for _, spec := range []string{"", "2of", "3of2", "0of1", "2of3;1of1", "2 of 3"} {
+		if _, err := ParseGroups(spec); err == nil {
+			t.Fatalf("%q: expect error", spec)
+		}
+	}
+	groups := []Group{{Parts: 3, Threshold: 2}, {Parts: 1, Threshold: 1}}
+	if _, err := SplitGroups([]byte("test"), 0, groups); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err := SplitGroups([]byte("test"), 3, groups); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err := SplitGroups([]byte("test"), 1, nil); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err := SplitGroups([]byte("test"), 1, []Group{{Parts: 2, Threshold: 3}}); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestGroupTotals(t *testing.T) {
+	groups := []Group{{Parts: 3, Threshold: 2}, {Parts: 1, Threshold: 1}, {Parts: 5, Threshold: 3}}
+	if parts, threshold := GroupTotals(groups, 2); parts != 9 || threshold != 3 {
+		t.Fatalf("expect 9 parts and threshold 3, actual %d and %d", parts, threshold)
+	}
+	if parts, threshold := GroupTotals(groups, 3); parts != 9 || threshold != 6 {
+		t.Fatalf("expect 9 parts and threshold 6, actual %d and %d", parts, threshold)
+	}
+	large := []Group{{Parts: 200, Threshold: 200}, {Parts: 200, Threshold: 100}}
+	if parts, threshold := GroupTotals(large, 2); parts != 255 || threshold != 255 {
+		t.Fatalf("expect 255 parts and threshold 255, actual %d and %d", parts, threshold)
+	}
+}
@@ -0,0 +1,166 @@
+package sss
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/wangkang/fortify/shamir"
+	"github.com/wangkang/fortify/utils"
+)
+
+// idaKeySize is the size of the AES-256 key encrypting a dispersed file.
+const idaKeySize = 32
+
+// newIdaSplitter splits a random key of a file into parts of the set, and returns a function
+// dispersing the encrypted blocks of the file among the parts, each about the size of the block /
+// threshold. Only threshold parts decrypt the blocks, as in Krawczyk's secret sharing made short.
+func newIdaSplitter(set string, parts, threshold uint8, blocks int) (func(block int, data []byte) ([]Part, error), error) {
+	key := make([]byte, idaKeySize)
+	defer utils.Wipe(key)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	raw, digest, err := blind(key)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.Wipe(raw)
+	var keyParts []Part
+	if keyParts, err = split(raw, digest, blindingSize, set, parts, threshold, SchemeIda); err != nil {
+		return nil, err
+	}
+	return idaSplitter(key, keyParts, blocks)
+}
+
+// resumeIdaSplitter recovers the key of a file from the first blocks of its part files, to
+// disperse its remaining blocks.
+func resumeIdaSplitter(paths []string, blocks int) (func(block int, data []byte) ([]Part, error), error) {
+	keyParts := make([]Part, len(paths))
+	for i, path := range paths {
+		p, err := readCandidate(path)
+		if err != nil {
+			return nil, err
+		}
+		if p.Scheme != SchemeIda || p.Part != i+1 {
+			return nil, fmt.Errorf("not a dispersed secret share of part %d: %s", i+1, path)
+		}
+		p.Payload = p.Key
+		keyParts[i] = p
+	}
+	raw, err := combineRaw(keyParts)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.Wipe(raw)
+	var key []byte
+	if key, err = unblind(keyParts, raw); err != nil {
+		return nil, err
+	}
+	defer utils.Wipe(key)
+	return idaSplitter(key, keyParts, blocks)
+}
+
+func idaSplitter(key []byte, keyParts []Part, blocks int) (func(block int, data []byte) ([]Part, error), error) {
+	aead, err := newIdaCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	first := keyParts[0]
+	return func(block int, data []byte) ([]Part, error) {
+		sealed := aead.Seal(nil, idaNonce(block), data, idaAdditionalData(first.Set, block, blocks))
+		shares, err := shamir.SplitIDA(sealed, int(first.Parts), int(first.Threshold))
+		if err != nil {
+			return nil, err
+		}
+		ps := make([]Part, len(keyParts))
+		for i, p := range keyParts {
+			if len(p.Key) == 0 {
+				p.Key = p.Payload
+			}
+			p.Payload = base64.URLEncoding.EncodeToString(shares[i])
+			p.Size = len(sealed)
+			p.Timestamp = time.Now()
+			ps[i] = p
+		}
+		return ps, nil
+	}, nil
+}
+
+// combineIda recovers the key from the parts of a block, then its ciphertext, and decrypts it.
+func combineIda(parts []Part) ([]byte, error) {
+	first := parts[0]
+	if len(parts) < int(first.Threshold) {
+		return nil, fmt.Errorf("need %d secret shares, got %d", first.Threshold, len(parts))
+	}
+	keyParts := make([]Part, len(parts))
+	shares := make([][]byte, len(parts))
+	for i, p := range parts {
+		if p.Scheme != SchemeIda || len(p.Key) == 0 {
+			return nil, fmt.Errorf("secret sharing scheme mismatch in file %v: expect %q, actual %q",
+				i+1, SchemeIda, p.Scheme)
+		}
+		if p.Size != first.Size {
+			return nil, fmt.Errorf("dispersed block size mismatch in file %v", i+1)
+		}
+		keyParts[i] = p
+		keyParts[i].Payload = p.Key
+		var err error
+		if shares[i], err = base64.URLEncoding.DecodeString(p.Payload); err != nil {
+			return nil, err
+		}
+	}
+	raw, err := combineRaw(keyParts)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.Wipe(raw)
+	var key []byte
+	if key, err = unblind(keyParts, raw); err != nil {
+		return nil, err
+	}
+	defer utils.Wipe(key)
+	var aead cipher.AEAD
+	if aead, err = newIdaCipher(key); err != nil {
+		return nil, err
+	}
+	var sealed []byte
+	if sealed, err = shamir.CombineIDA(shares, int(first.Threshold)); err != nil {
+		return nil, err
+	}
+	if first.Size > len(sealed) {
+		return nil, errors.New("dispersed block size exceeds the secret shares")
+	}
+	block := first.Block - 1
+	data, err := aead.Open(nil, idaNonce(block), sealed[:first.Size], idaAdditionalData(first.Set, block, first.Blocks))
+	if err != nil {
+		return nil, fmt.Errorf("dispersed block %d does not decrypt: %v", first.Block, err)
+	}
+	return data, nil
+}
+
+func newIdaCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// idaNonce numbers the blocks, which are all encrypted with the random key of the file.
+func idaNonce(block int) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce[4:], uint64(block))
+	return nonce
+}
+
+// idaAdditionalData binds a block to its set and position, so that blocks cannot be reordered,
+// dropped or mixed between files.
+func idaAdditionalData(set string, block, blocks int) []byte {
+	return []byte(fmt.Sprintf("fortify sss ida\x00%s\x00%d\x00%d", set, block, blocks))
+}
@@ -0,0 +1,320 @@
+package sss
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// FileInfo describes a share file from the metadata of its parts, whose payloads are left alone.
+type FileInfo struct {
+	Path           string     `json:"path"`
+	Error          string     `json:"error,omitempty"`
+	Set            string     `json:"set,omitempty"`
+	Digest         string     `json:"digest,omitempty"`
+	Scheme         string     `json:"scheme,omitempty"`
+	Part           int        `json:"part"`
+	Parts          uint8      `json:"parts"`
+	Threshold      uint8      `json:"threshold"`
+	Weight         int        `json:"weight"`
+	Group          int        `json:"group,omitempty"`
+	Groups         string     `json:"groups,omitempty"`
+	GroupThreshold uint8      `json:"group_threshold,omitempty"`
+	Blocks         int        `json:"blocks"`
+	BlocksFound    int        `json:"blocks_found"`
+	Holder         string     `json:"holder,omitempty"`
+	NotAfter       *time.Time `json:"not_after,omitempty"`
+	Expired        bool       `json:"expired,omitempty"`
+	Sealed         bool       `json:"sealed,omitempty"`
+	Dealer         string     `json:"dealer,omitempty"`
+	// Problems are what keeps the file from being combined with the others of its set.
+	Problems []string `json:"problems,omitempty"`
+	first    Part
+	digests  []string
+}
+
+// Duplicate lists the files holding the same part of a set.
+type Duplicate struct {
+	Group int      `json:"group,omitempty"`
+	Part  int      `json:"part"`
+	Files []string `json:"files"`
+}
+
+// SetInfo describes the share files of one set, and whether they reach its threshold.
+type SetInfo struct {
+	Set            string      `json:"set,omitempty"`
+	Digest         string      `json:"digest,omitempty"`
+	Scheme         string      `json:"scheme,omitempty"`
+	Parts          uint8       `json:"parts,omitempty"`
+	Threshold      uint8       `json:"threshold,omitempty"`
+	Groups         string      `json:"groups,omitempty"`
+	GroupThreshold uint8       `json:"group_threshold,omitempty"`
+	Blocks         int         `json:"blocks"`
+	Files          []string    `json:"files"`
+	Usable         int         `json:"usable"`
+	Weight         int         `json:"weight"`
+	Duplicates     []Duplicate `json:"duplicates,omitempty"`
+	Reachable      bool        `json:"reachable"`
+	Shortfall      string      `json:"shortfall,omitempty"`
+	Problems       []string    `json:"problems,omitempty"`
+}
+
+// Report is what InspectKeyParts finds in share files.
+type Report struct {
+	Files []FileInfo `json:"files"`
+	Sets  []SetInfo  `json:"sets"`
+}
+
+// Reachable reports whether the files of some set reach its threshold.
+func (r Report) Reachable() bool {
+	return slices.ContainsFunc(r.Sets, func(s SetInfo) bool { return s.Reachable })
+}
+
+// InspectKeyParts reads the share files named by the arguments, which may also be directories and
+// glob patterns as for DiscoverKeyParts, and tells which sets they belong to, whether their blocks
+// are complete, which parts are duplicated and whether the threshold of each set is reachable. It
+// never combines or decodes the payloads. Files found in directories which are not share files are
+// skipped; the files named explicitly are always reported.
+func InspectKeyParts(args []string) (Report, error) {
+	var r Report
+	for _, arg := range args {
+		found, err := expandKeyPartArg(arg)
+		if err != nil {
+			return r, err
+		}
+		if found == nil {
+			r.Files = append(r.Files, inspectFile(arg))
+			continue
+		}
+		for _, path := range found {
+			if info := inspectFile(path); len(info.Error) == 0 {
+				r.Files = append(r.Files, info)
+			}
+		}
+	}
+	if len(r.Files) == 0 {
+		return r, errors.New("no share files found")
+	}
+	var keys []string
+	sets := make(map[string][]*FileInfo)
+	for i := range r.Files {
+		f := &r.Files[i]
+		if len(f.Error) > 0 {
+			continue
+		}
+		key := setKey(f.first)
+		if _, ok := sets[key]; !ok {
+			keys = append(keys, key)
+		}
+		sets[key] = append(sets[key], f)
+	}
+	for _, key := range keys {
+		r.Sets = append(r.Sets, inspectSet(sets[key]))
+	}
+	return r, nil
+}
+
+// inspectFile reads the metadata of every block of a share file, or of a part written as words
+// or recovery kit text. Only the part of the first block is kept.
+func inspectFile(path string) (info FileInfo) {
+	info.Path = path
+	var parts int
+	check := func(p Part) {
+		parts++
+		if parts == 1 {
+			info.first = p
+		}
+		info.digests = append(info.digests, p.Digest)
+		first := info.first
+		if len(info.Problems) > 0 {
+			// The first problem of a file is enough to tell.
+			return
+		}
+		switch {
+		case p.Block > 0 && p.Block != parts:
+			info.problem("holds block %d where block %d belongs", p.Block, parts)
+		case p.Blocks != first.Blocks || p.Set != first.Set || p.Part != first.Part || p.Group != first.Group ||
+			p.Parts != first.Parts || p.Threshold != first.Threshold || p.Scheme != first.Scheme:
+			info.problem("block %d is not of part %d of set %s", parts, first.Part, setKey(first))
+		case len(p.Payload) == 0:
+			info.problem("block %d has no payload", parts)
+		default:
+			if err := verifyDealer(p, path); err != nil {
+				info.problem("block %d: %s", parts, strings.Replace(err.Error(), " in file "+path, "", 1))
+			}
+		}
+	}
+	err := readKeyPartBlocks(path, check)
+	if err == nil && parts == 0 {
+		err = fmt.Errorf("not a valid sss key part: %s", path)
+	}
+	if err != nil {
+		info.Error, info.Problems, info.digests = err.Error(), nil, nil
+		return
+	}
+	first := info.first
+	info.Set, info.Digest, info.Scheme = first.Set, first.Digest, first.Scheme
+	info.Part, info.Parts, info.Threshold, info.Weight = first.Part, first.Parts, first.Threshold, first.Weight()
+	info.Group, info.Groups, info.GroupThreshold = first.Group, first.Groups, first.GroupThreshold
+	info.Blocks, info.BlocksFound = first.Blocks, parts
+	info.Holder, info.NotAfter, info.Dealer = first.Holder, first.NotAfter, first.Dealer
+	info.Sealed = first.Seal != nil
+	info.Expired = first.NotAfter != nil && time.Now().After(*first.NotAfter)
+	if info.Blocks == 0 {
+		// Parts read from words or recovery kit text hold a single block.
+		info.Blocks = 1
+	}
+	if info.BlocksFound != info.Blocks {
+		info.problem("holds %d of %d blocks", info.BlocksFound, info.Blocks)
+	}
+	return
+}
+
+func (info *FileInfo) problem(format string, a ...any) {
+	info.Problems = append(info.Problems, fmt.Sprintf(format, a...))
+}
+
+// readKeyPartBlocks passes the part of every block of a share file, as written by AppendParts, or
+// the part of a key part file holding words or recovery kit text, to fn.
+func readKeyPartBlocks(path string, fn func(Part)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	reader := bufio.NewReaderSize(file, 4096)
+	var head []byte
+	if head, err = reader.Peek(1); err != nil {
+		return fmt.Errorf("not a valid sss key part: %s", path)
+	}
+	if head[0] != '{' {
+		var content []byte
+		if content, err = io.ReadAll(io.LimitReader(reader, 64*1024)); err != nil {
+			return err
+		}
+		if !utf8.Valid(content) {
+			return fmt.Errorf("not a valid sss key part: %s", path)
+		}
+		var p Part
+		if p, err = parseKeyPart(path, content); err != nil {
+			return err
+		}
+		fn(p)
+		return nil
+	}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, maxScannerTokenSize), maxScannerTokenSize)
+	for block := 1; scanner.Scan(); {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var p Part
+		if err = json.Unmarshal(line, &p); err != nil {
+			return fmt.Errorf("block %d of %s is not a valid sss key part\nCaused by: %v", block, path, err)
+		}
+		fn(p)
+		block++
+	}
+	return scanner.Err()
+}
+
+// inspectSet checks that the files of a set agree on the set and on the digests of their blocks,
+// finds the files holding the same part, and tells whether the other files reach the threshold.
+func inspectSet(fs []*FileInfo) SetInfo {
+	first := fs[0]
+	s := SetInfo{
+		Set:            first.Set,
+		Digest:         first.Digest,
+		Scheme:         first.Scheme,
+		Parts:          first.Parts,
+		Threshold:      first.Threshold,
+		Groups:         first.Groups,
+		GroupThreshold: first.GroupThreshold,
+		Blocks:         first.Blocks,
+	}
+	if len(s.Groups) > 0 {
+		// Parts and threshold count the members of each group.
+		s.Parts, s.Threshold = 0, 0
+	} else {
+		// The parts of an extended set record different numbers of parts, the latest the most.
+		for _, f := range fs {
+			s.Parts = max(s.Parts, f.Parts)
+		}
+	}
+	// The digests of the blocks held by most files are taken for those of the set.
+	vectors := make(map[string]int)
+	for _, f := range fs {
+		vectors[strings.Join(f.digests, ",")]++
+	}
+	majority := strings.Join(first.digests, ",")
+	for _, f := range fs {
+		if v := strings.Join(f.digests, ","); vectors[v] > vectors[majority] {
+			majority = v
+		}
+	}
+	for _, f := range fs {
+		s.Files = append(s.Files, f.Path)
+		switch {
+		case len(f.Problems) > 0:
+		case f.Groups != s.Groups || f.GroupThreshold != s.GroupThreshold || f.Scheme != s.Scheme:
+			f.problem("differs from %s in its groups or scheme", first.Path)
+		case len(s.Groups) == 0 && f.Threshold != s.Threshold:
+			f.problem("differs from %s in its threshold", first.Path)
+		case f.Blocks != s.Blocks:
+			f.problem("has %d blocks, not %d as %s", f.Blocks, s.Blocks, first.Path)
+		case strings.Join(f.digests, ",") != majority && !slices.Contains(f.digests, ""):
+			f.problem("block digests differ from those of the other files of the set")
+		}
+	}
+	type index struct{ group, part int }
+	var order []index
+	holders := make(map[index][]*FileInfo)
+	for _, f := range fs {
+		i := index{f.Group, f.Part}
+		if _, ok := holders[i]; !ok {
+			order = append(order, i)
+		}
+		holders[i] = append(holders[i], f)
+	}
+	var usable []candidate
+	for _, i := range order {
+		hs := holders[i]
+		if len(hs) > 1 {
+			d := Duplicate{Group: i.group, Part: i.part}
+			for _, f := range hs {
+				d.Files = append(d.Files, f.Path)
+			}
+			s.Duplicates = append(s.Duplicates, d)
+		}
+		// A duplicated part counts once, from the first of its files without problems.
+		for _, f := range hs {
+			if len(f.Problems) == 0 {
+				usable = append(usable, candidate{path: f.Path, part: f.first})
+				s.Weight += f.Weight
+				break
+			}
+		}
+	}
+	s.Usable = len(usable)
+	for _, f := range fs {
+		for _, p := range f.Problems {
+			s.Problems = append(s.Problems, fmt.Sprintf("%s %s", f.Path, p))
+		}
+	}
+	if len(usable) == 0 {
+		s.Shortfall = "no usable share files"
+		return s
+	}
+	s.Shortfall = describeShortfall(usable)
+	s.Reachable = len(s.Shortfall) == 0
+	return s
+}
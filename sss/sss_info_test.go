@@ -0,0 +1,188 @@
+package sss
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestInspectKeyParts(t *testing.T) {
+	dir := t.TempDir()
+	copies := filepath.Join(dir, "copies")
+	if err := os.Mkdir(copies, 0700); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ps, err := Split([]byte("test info"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	writeTestParts(t, ps[:1], filepath.Join(dir, "a"))
+	writeTestParts(t, ps[:1], filepath.Join(copies, "a"))
+	notes := filepath.Join(dir, "notes.txt")
+	if err = os.WriteFile(notes, []byte("not a share\n"), 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The same part in two files counts once.
+	r, err := InspectKeyParts([]string{dir, copies})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(r.Files) != 2 || len(r.Sets) != 1 {
+		t.Fatalf("expect 2 files of 1 set, actual %d files of %d sets", len(r.Files), len(r.Sets))
+	}
+	s := r.Sets[0]
+	if s.Set != ps[0].Set || s.Parts != 3 || s.Threshold != 2 || s.Usable != 1 || s.Weight != 1 {
+		t.Fatalf("unexpected set %+v", s)
+	}
+	if len(s.Duplicates) != 1 || s.Duplicates[0].Part != 1 || len(s.Duplicates[0].Files) != 2 {
+		t.Fatalf("expect part 1 duplicated, actual %+v", s.Duplicates)
+	}
+	if s.Reachable || len(s.Shortfall) == 0 || r.Reachable() {
+		t.Fatalf("expect the threshold unreachable, actual %+v", s)
+	}
+
+	// Another part reaches the threshold.
+	writeTestParts(t, ps[1:2], filepath.Join(dir, "a"))
+	if r, err = InspectKeyParts([]string{dir, copies}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	s = r.Sets[0]
+	if !s.Reachable || len(s.Shortfall) > 0 || s.Usable != 2 || len(s.Duplicates) != 1 || !r.Reachable() {
+		t.Fatalf("expect the threshold reachable, actual %+v", s)
+	}
+
+	// A file named explicitly is reported even when it is not a share file.
+	if r, err = InspectKeyParts([]string{notes, dir}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(r.Files) != 3 || r.Files[0].Path != notes || len(r.Files[0].Error) == 0 {
+		t.Fatalf("expect an error for %s, actual %+v", notes, r.Files)
+	}
+	if len(r.Sets) != 1 || !r.Reachable() {
+		t.Fatalf("expect 1 reachable set, actual %+v", r.Sets)
+	}
+
+	// A pattern matching no files is reported as a file, and a directory of no share files is not.
+	pattern := filepath.Join(dir, "none-*.json")
+	if r, err = InspectKeyParts([]string{pattern}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(r.Files) != 1 || len(r.Files[0].Error) == 0 || len(r.Sets) > 0 || r.Reachable() {
+		t.Fatalf("expect an error for %s, actual %+v", pattern, r)
+	}
+	if _, err = InspectKeyParts([]string{t.TempDir()}); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestInspectKeyParts_problems(t *testing.T) {
+	dir := t.TempDir()
+	ps, err := Split([]byte("test info"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// The second of two blocks is missing.
+	if err = AppendParts(ps, 0, 2, filepath.Join(dir, "a"), true); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	CloseAllFilesForWrite()
+	broken := filepath.Join(dir, "broken.json")
+	if err = os.WriteFile(broken, []byte("{\"part\":\n"), 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	r, err := InspectKeyParts([]string{broken, dir})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(r.Files) != 4 || len(r.Files[0].Error) == 0 {
+		t.Fatalf("expect an error for %s, actual %+v", broken, r.Files)
+	}
+	for _, f := range r.Files[1:] {
+		if f.Blocks != 2 || f.BlocksFound != 1 || len(f.Problems) != 1 {
+			t.Fatalf("expect 1 of 2 blocks found, actual %+v", f)
+		}
+	}
+	if len(r.Sets) != 1 || r.Sets[0].Usable != 0 || len(r.Sets[0].Problems) != 3 || r.Reachable() {
+		t.Fatalf("expect no usable files, actual %+v", r.Sets)
+	}
+}
+
+func TestInspectKeyParts_extended(t *testing.T) {
+	dir := t.TempDir()
+	ps, err := Split([]byte("test info"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	extended, err := Extend(ps[1:], 0)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// The older part records 3 parts, the extended one 4, and they combine.
+	writeTestParts(t, []Part{ps[0], extended}, filepath.Join(dir, "a"))
+	r, err := InspectKeyParts([]string{dir})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(r.Sets) != 1 {
+		t.Fatalf("expect 1 set, actual %d", len(r.Sets))
+	}
+	s := r.Sets[0]
+	if s.Parts != 4 || s.Threshold != 2 || s.Usable != 2 || len(s.Problems) > 0 || !s.Reachable || !r.Reachable() {
+		t.Fatalf("expect the extended set reachable, actual %+v", s)
+	}
+
+	// A part of another threshold is still a problem.
+	other := ps[1]
+	other.Threshold = 3
+	writeTestParts(t, []Part{other}, filepath.Join(dir, "b"))
+	if r, err = InspectKeyParts([]string{dir}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if s = r.Sets[0]; s.Usable != 2 || len(s.Problems) != 1 {
+		t.Fatalf("expect a problem of the threshold, actual %+v", s)
+	}
+}
+
+func TestInspectKeyParts_groups(t *testing.T) {
+	dir := t.TempDir()
+	groups, err := ParseGroups("2of3,1of1")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	gs, err := SplitGroups([]byte("test info"), 2, groups)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	writeTestParts(t, gs[0][:2], filepath.Join(dir, "g1-"))
+	r, err := InspectKeyParts([]string{dir})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(r.Sets) != 1 || r.Reachable() {
+		t.Fatalf("expect 1 unreachable set, actual %+v", r.Sets)
+	}
+	// Parts and threshold count the members of each group, and are left out of the set.
+	s := r.Sets[0]
+	if s.Groups != "2of3,1of1" || s.GroupThreshold != 2 || s.Parts != 0 || s.Threshold != 0 || s.Usable != 2 {
+		t.Fatalf("unexpected set %+v", s)
+	}
+
+	writeTestParts(t, gs[1], filepath.Join(dir, "g2-"))
+	if r, err = InspectKeyParts([]string{dir}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(r.Sets) != 1 || !r.Reachable() || r.Sets[0].Usable != 3 || len(r.Sets[0].Duplicates) > 0 {
+		t.Fatalf("expect 1 reachable set, actual %+v", r.Sets)
+	}
+	groupsOf := func(fs []FileInfo) (gs []int) {
+		for _, f := range fs {
+			gs = append(gs, f.Group)
+		}
+		return
+	}
+	if g := groupsOf(r.Files); !slices.Equal(g, []int{1, 1, 2}) {
+		t.Fatalf("expect groups [1 1 2], actual %v", g)
+	}
+}
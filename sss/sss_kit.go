@@ -0,0 +1,219 @@
+package sss
+
+import (
+	"encoding/base32"
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"strings"
+	"time"
+
+	"rsc.io/qr"
+)
+
+// KitTextPrefix starts the text of the QR code on a recovery kit page. The text is upper case
+// base32 so that the QR code can use its compact alphanumeric mode.
+const KitTextPrefix = "FORTIFY:SSS:"
+
+const (
+	KitFormatHtml = "html"
+	KitFormatSvg  = "svg"
+)
+
+var kitEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// KitText encodes a part as the text of the QR code on its recovery kit page.
+func KitText(p Part) (string, error) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	return KitTextPrefix + kitEncoding.EncodeToString(b), nil
+}
+
+// IsKitText reports whether the content of a key part file is the text of a recovery kit QR code.
+func IsKitText(content []byte) bool {
+	s := strings.TrimSpace(string(content))
+	return len(s) >= len(KitTextPrefix) && strings.EqualFold(s[:len(KitTextPrefix)], KitTextPrefix)
+}
+
+// ParseKitText decodes a part from the text of a recovery kit QR code, as scanned by a phone.
+func ParseKitText(text string) (Part, error) {
+	var p Part
+	text = strings.TrimSpace(text)
+	if !IsKitText([]byte(text)) {
+		return p, fmt.Errorf("recovery kit text must start with %s", KitTextPrefix)
+	}
+	b, err := kitEncoding.DecodeString(strings.ToUpper(text[len(KitTextPrefix):]))
+	if err != nil {
+		return p, fmt.Errorf("invalid recovery kit text: %v", err)
+	}
+	if err = json.Unmarshal(b, &p); err != nil {
+		return p, fmt.Errorf("invalid recovery kit text: %v", err)
+	}
+	return p, nil
+}
+
+// WriteKitFile writes a self-contained printable recovery kit page for a part, in HTML or SVG.
+// The page holds a QR code of the part along with the holder, set ID, threshold and instructions.
+// An empty holder falls back to the holder recorded in the part.
+func WriteKitFile(p Part, holder, format, path string, truncate bool) error {
+	text, err := KitText(p)
+	if err != nil {
+		return err
+	}
+	var code *qr.Code
+	if code, err = qr.Encode(text, qr.M); err != nil {
+		return fmt.Errorf("secret share %d cannot be encoded as a QR code: %v", p.Part, err)
+	}
+	var page string
+	switch format {
+	case KitFormatHtml:
+		page = kitHtml(p, holder, code)
+	case KitFormatSvg:
+		page = kitSvg(p, holder, code)
+	default:
+		return fmt.Errorf("unknown recovery kit format: %s", format)
+	}
+	var file *os.File
+	if file, err = OpenFileForWrite(path, truncate); err != nil {
+		return err
+	}
+	if err = file.Truncate(0); err != nil {
+		return err
+	}
+	_, err = file.WriteString(page)
+	return err
+}
+
+// kitLines returns the text lines of a recovery kit page.
+func kitLines(p Part, holder string) []string {
+	if len(holder) == 0 {
+		holder = p.Holder
+	}
+	if len(holder) == 0 {
+		holder = "________________________"
+	}
+	set := p.Set
+	if len(set) == 0 {
+		set = "(not recorded)"
+	}
+	lines := []string{
+		fmt.Sprintf("Holder: %s", holder),
+		fmt.Sprintf("Secret share %d of %d", p.Part, p.Parts),
+		fmt.Sprintf("Set ID: %s", set),
+		fmt.Sprintf("Threshold: any %d shares of this set recover the secret", p.Threshold),
+		fmt.Sprintf("Created: %s", p.Timestamp.Format("2006-01-02")),
+	}
+	if len(p.Labels) > 0 {
+		lines = append(lines, fmt.Sprintf("Labels: %s", strings.Join(p.Labels, ", ")))
+	}
+	if p.NotAfter != nil {
+		lines = append(lines, fmt.Sprintf("Not after: %s", p.NotAfter.Format(time.DateOnly)))
+	}
+	if p.Seal != nil {
+		lines = append(lines, "Sealed: the passphrase of the holder is asked for on recovery")
+	}
+	if len(p.Groups) > 0 {
+		lines[1] = fmt.Sprintf("Secret share %d of %d in group %d", p.Part, p.Parts, p.Group)
+		lines[3] = fmt.Sprintf("Threshold: any %d shares of group %d, in %d of the groups %s, recover the secret",
+			p.Threshold, p.Group, p.GroupThreshold, p.Groups)
+	}
+	return lines
+}
+
+// kitInstructions returns the recovery instructions printed on every recovery kit page.
+func kitInstructions(p Part) []string {
+	gather := fmt.Sprintf("1. Gather the kits of at least %d holders of set %s.", p.Threshold, p.Set)
+	if len(p.Groups) > 0 {
+		gather = fmt.Sprintf("1. Gather the kits of enough holders of set %s to satisfy %d of the groups %s.",
+			p.Set, p.GroupThreshold, p.Groups)
+	}
+	return []string{
+		gather,
+		fmt.Sprintf("2. Scan each QR code; the text starts with %s.", KitTextPrefix),
+		"3. Save each text into a file, or paste it when reading key parts from \"-\".",
+		"4. Run: fortify decrypt -i <encrypted-file> -o <output-file> <share1> <share2> ...",
+		"Keep this page offline and out of sight. Never scan it with an online service.",
+	}
+}
+
+// kitModules returns the SVG path drawing the dark modules of a QR code, one unit per module,
+// offset by the quiet zone.
+func kitModules(code *qr.Code, quiet int) string {
+	var b strings.Builder
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if code.Black(x, y) {
+				_, _ = fmt.Fprintf(&b, "M%d %dh1v1h-1z", x+quiet, y+quiet)
+			}
+		}
+	}
+	return b.String()
+}
+
+func kitQrSvg(code *qr.Code, x, y, size int) string {
+	const quiet = 4
+	n := code.Size + 2*quiet
+	return fmt.Sprintf(`<svg x="%d" y="%d" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="%s"/></svg>`,
+		x, y, size, size, n, n, n, n, kitModules(code, quiet))
+}
+
+func kitHtml(p Part, holder string, code *qr.Code) string {
+	var b strings.Builder
+	title := html.EscapeString(fmt.Sprintf("Fortify recovery kit -- share %d of %d", p.Part, p.Parts))
+	_, _ = fmt.Fprintf(&b, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+@page { size: A4; margin: 15mm; }
+.qr { width: 90mm; height: 90mm; }
+.field { font-size: 14pt; margin: 0.3em 0; }
+.instructions { margin-top: 1.5em; font-size: 11pt; }
+</style>
+</head>
+<body>
+<h1>%s</h1>
+`, title, title)
+	_, _ = fmt.Fprintf(&b, "<div class=\"qr\">%s</div>\n", kitQrSvg(code, 0, 0, 340))
+	for _, line := range kitLines(p, holder) {
+		_, _ = fmt.Fprintf(&b, "<p class=\"field\">%s</p>\n", html.EscapeString(line))
+	}
+	b.WriteString("<div class=\"instructions\">\n<h2>Recovery</h2>\n")
+	for _, line := range kitInstructions(p) {
+		_, _ = fmt.Fprintf(&b, "<p>%s</p>\n", html.EscapeString(line))
+	}
+	b.WriteString("</div>\n</body>\n</html>\n")
+	return b.String()
+}
+
+func kitSvg(p Part, holder string, code *qr.Code) string {
+	const width, height, margin, qrSize, lineHeight = 794, 1123, 60, 400, 28
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif">
+<rect width="%d" height="%d" fill="#fff"/>
+`, width, height, width, height, width, height)
+	_, _ = fmt.Fprintf(&b, "<text x=\"%d\" y=\"%d\" font-size=\"26\" font-weight=\"bold\">%s</text>\n", margin, margin,
+		html.EscapeString(fmt.Sprintf("Fortify recovery kit -- share %d of %d", p.Part, p.Parts)))
+	b.WriteString(kitQrSvg(code, margin, margin+20, qrSize) + "\n")
+	y := margin + 20 + qrSize + 2*lineHeight
+	for _, line := range kitLines(p, holder) {
+		_, _ = fmt.Fprintf(&b, "<text x=\"%d\" y=\"%d\" font-size=\"18\">%s</text>\n", margin, y, html.EscapeString(line))
+		y += lineHeight
+	}
+	y += lineHeight
+	_, _ = fmt.Fprintf(&b, "<text x=\"%d\" y=\"%d\" font-size=\"20\" font-weight=\"bold\">Recovery</text>\n", margin, y)
+	y += lineHeight
+	for _, line := range kitInstructions(p) {
+		_, _ = fmt.Fprintf(&b, "<text x=\"%d\" y=\"%d\" font-size=\"14\">%s</text>\n", margin, y, html.EscapeString(line))
+		y += lineHeight - 6
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package sss
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rsc.io/qr"
+)
+
+func TestWriteKitFile(t *testing.T) {
+	dir := t.TempDir()
+	secret := []byte("test recovery kit")
+	ps, err := Split(secret, 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var shares []string
+	for i, p := range ps {
+		format := []string{KitFormatHtml, KitFormatSvg}[i%2]
+		path := filepath.Join(dir, fmt.Sprintf("kit%dof%d.%s", p.Part, p.Parts, format))
+		if err = WriteKitFile(p, "alice", format, path, true); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		CloseAllFilesForWrite()
+		page, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !strings.Contains(string(page), "Holder: alice") || !strings.Contains(string(page), "Set ID: "+p.Set) {
+			t.Fatalf("part %d: expect the holder and set on the page", p.Part)
+		}
+
+		// The page draws the QR code of the kit text, which decodes to the same part.
+		text, err := KitText(p)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		code, err := qr.Encode(text, qr.M)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !strings.Contains(string(page), kitModules(code, 4)) {
+			t.Fatalf("part %d: expect the QR code of the kit text on the page", p.Part)
+		}
+		q, err := ParseKitText(text)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		expect, _ := json.Marshal(p)
+		actual, _ := json.Marshal(q)
+		if !bytes.Equal(expect, actual) {
+			t.Fatalf("part %d: expect %s, actual %s", p.Part, expect, actual)
+		}
+
+		// A phone may scan the text in lower case.
+		if i == 1 {
+			text = strings.ToLower(text)
+		}
+		share := filepath.Join(dir, fmt.Sprintf("scan%d.txt", p.Part))
+		if err = os.WriteFile(share, []byte(text+"\n"), 0600); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		shares = append(shares, share)
+	}
+
+	parts, err := CombineKeyFiles(shares[1:])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	recombined, err := Combine(parts)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recombined, secret) {
+		t.Fatalf("expect %q, actual %q", secret, recombined)
+	}
+
+	if err = WriteKitFile(ps[0], "", "pdf", filepath.Join(dir, "kit.pdf"), true); err == nil {
+		t.Fatalf("expect error")
+	}
+	for _, text := range []string{"FORTIFY:SSS:", "FORTIFY:SSS:1", "FORTIFY:SSS:MZXW6", "FORTIFY:PART:MZXW6"} {
+		if _, err = ParseKitText(text); err == nil {
+			t.Fatalf("%s: expect error", text)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package sss
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Labels describe the parts of a set to their holders. They are written into the parts after the
+// split, and are not authenticated.
+type Labels struct {
+	Labels   []string
+	Holders  []string // holder name and email of every part, in the order of the parts
+	NotAfter *time.Time
+}
+
+// Apply writes the labels into the parts.
+func (l Labels) Apply(ps []Part) error {
+	if len(l.Holders) > len(ps) {
+		return fmt.Errorf("%d holders given for %d secret shares", len(l.Holders), len(ps))
+	}
+	for i := range ps {
+		ps[i].Labels = l.Labels
+		ps[i].NotAfter = l.NotAfter
+		if i < len(l.Holders) {
+			ps[i].Holder = l.Holders[i]
+		}
+	}
+	return nil
+}
+
+// ParseNotAfter parses a not-after date given as 2006-01-02, meaning the end of that day in UTC,
+// or as an RFC 3339 time.
+func ParseNotAfter(s string) (*time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		t = t.Add(24*time.Hour - time.Second)
+		return &t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid not-after date %q, expect 2006-01-02 or RFC 3339", s)
+	}
+	return &t, nil
+}
+
+// setMacKey returns the key authenticating the set of the raw secret. Like HMAC itself, it hashes
+// keys longer than a block, once instead of for every part.
+func setMacKey(raw []byte) []byte {
+	if len(raw) > sha256.BlockSize {
+		sum := sha256.Sum256(raw)
+		return sum[:]
+	}
+	return raw
+}
+
+// setMac authenticates the set ID and the sharing parameters of a part with the key of the raw
+// secret, which only the holders of enough parts can reconstruct. A forged set ID is thus
+// detected on combining.
+func setMac(key []byte, p Part) string {
+	mac := hmac.New(sha256.New, key)
+	_, _ = fmt.Fprintf(mac, "fortify sss set\x00%s\x00%d\x00%d\x00%s\x00%d\x00%s\x00%d",
+		p.Set, p.Threshold, p.Blinding, p.Scheme, p.Group, p.Groups, p.GroupThreshold)
+	return base64.URLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// authenticateSet verifies the set ID of every part against the raw secret. Every part carrying a
+// set ID must carry its authenticator, whole or, for parts read from words, its first bytes, and a
+// part without one is refused among parts with one. Only sets without any set ID, such as old or
+// imported ones, go unauthenticated.
+func authenticateSet(parts []Part, raw []byte) error {
+	authenticated := slices.ContainsFunc(parts, func(p Part) bool {
+		return len(p.SetMac) > 0 || len(p.setMacCheck) > 0
+	})
+	key := setMacKey(raw)
+	for i, p := range parts {
+		expect := setMac(key, p)
+		switch {
+		case len(p.SetMac) > 0:
+			if !hmac.Equal([]byte(p.SetMac), []byte(expect)) {
+				return fmt.Errorf("secret share in file %v does not authenticate as part of set %s", i+1, p.Set)
+			}
+		case len(p.setMacCheck) > 0:
+			mac, _ := base64.URLEncoding.DecodeString(expect)
+			if !hmac.Equal(p.setMacCheck, mac[:min(len(mac), len(p.setMacCheck))]) {
+				return fmt.Errorf("secret share in file %v does not authenticate as part of set %s", i+1, p.Set)
+			}
+		case len(p.Set) > 0:
+			return fmt.Errorf("secret share in file %v carries set %s without its authenticator", i+1, p.Set)
+		case authenticated:
+			return fmt.Errorf("secret share in file %v carries no set authenticator, unlike the other files", i+1)
+		}
+	}
+	return nil
+}
+
+// describePart names a key part file along with its part number, set and holder.
+func describePart(name string, p Part) string {
+	s := fmt.Sprintf("%s (part %d of set %s", name, p.Part, p.Set)
+	if len(p.Holder) > 0 {
+		s += ", held by " + p.Holder
+	}
+	return s + ")"
+}
+
+// checkKeyParts refuses key parts of different sets, and warns about expired key parts.
+func checkKeyParts(names []string, parts []Part) error {
+	first := -1
+	for i, p := range parts {
+		if len(p.Set) == 0 {
+			continue
+		}
+		if first < 0 {
+			first = i
+		} else if p.Set != parts[first].Set {
+			return fmt.Errorf("key parts of different sets cannot be combined: %s and %s",
+				describePart(names[first], parts[first]), describePart(names[i], p))
+		}
+	}
+	now := time.Now()
+	for i, p := range parts {
+		if p.NotAfter != nil && now.After(*p.NotAfter) {
+			fmt.Printf("Warning: key part %s expired on %s\n", describePart(names[i], p), p.NotAfter.Format(time.DateOnly))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package sss
+
+import (
+	"testing"
+)
+
+func TestAuthenticateSet(t *testing.T) {
+	ps, err := Split([]byte("test"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err = Combine(ps[:2]); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// A part stripped of its authenticator among authenticated parts
+	stripped := []Part{ps[0], ps[1]}
+	stripped[1].SetMac = ""
+	if _, err = Combine(stripped); err == nil {
+		t.Fatalf("expect error")
+	}
+
+	// Parts carrying a set ID without any authenticator
+	stripped[0].SetMac = ""
+	if _, err = Combine(stripped); err == nil {
+		t.Fatalf("expect error")
+	}
+
+	// A forged set ID
+	forged := []Part{ps[0], ps[1]}
+	forged[0].Set, forged[1].Set = "0123456789abcdef", "0123456789abcdef"
+	if _, err = Combine(forged); err == nil {
+		t.Fatalf("expect error")
+	}
+
+	// Parts of sets without a set ID, such as imported ones
+	imported := []Part{ps[0], ps[1]}
+	for i := range imported {
+		imported[i].Set, imported[i].SetMac = "", ""
+	}
+	if _, err = Combine(imported); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	imported[0].SetMac = ps[0].SetMac
+	if _, err = Combine(imported); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestAuthenticateSet_words(t *testing.T) {
+	ps, err := Split([]byte("test"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var parts []Part
+	for _, p := range ps[1:] {
+		words, err := EncodeWords(p)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		q, err := DecodeWords(words)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if len(q.setMacCheck) != wordsSetMacSize {
+			t.Fatalf("part %d: bad set authenticator %x", q.Part, q.setMacCheck)
+		}
+		parts = append(parts, q)
+	}
+	if _, err = Combine(parts); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	parts[0].Set = "0123456789abcdef"
+	if _, err = Combine(parts); err == nil {
+		t.Fatalf("expect error")
+	}
+}
@@ -0,0 +1,104 @@
+package sss
+
+import (
+	"runtime"
+	"sync"
+)
+
+// inOrder runs work on the jobs returned by next, until it returns false, with a pool of workers,
+// and passes the results to write in the order of the jobs. Only a few jobs are in flight at once.
+// It stops at the first error, and returns once every worker is done, after passing the results
+// not written to discard if not nil.
+func inOrder[J, R any](next func() (J, bool, error), work func(J) (R, error), write func(R) error,
+	discard func(R)) error {
+	type job struct {
+		index int
+		job   J
+	}
+	type result struct {
+		index  int
+		result R
+		err    error
+	}
+	workers := runtime.NumCPU()
+	jobs := make(chan job)
+	results := make(chan result, workers)
+	tokens := make(chan struct{}, 2*workers)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(workers + 1)
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+		for index := 0; ; index++ {
+			select {
+			case tokens <- struct{}{}:
+			case <-done:
+				return
+			}
+			j, ok, err := next()
+			if err != nil {
+				select {
+				case results <- result{index: index, err: err}:
+				case <-done:
+				}
+				return
+			}
+			if !ok {
+				return
+			}
+			select {
+			case jobs <- job{index, j}:
+			case <-done:
+				return
+			}
+		}
+	}()
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				// Results are drained on return, so that none is dropped unwiped.
+				r, err := work(j.job)
+				results <- result{j.index, r, err}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	pending := make(map[int]result)
+	defer func() {
+		// Stop the goroutines, and wait for them by draining the results until they are closed.
+		close(done)
+		for r := range results {
+			pending[r.index] = r
+		}
+		if discard != nil {
+			for _, r := range pending {
+				discard(r.result)
+			}
+		}
+	}()
+	index := 0
+	for r := range results {
+		pending[r.index] = r
+		for {
+			p, ok := pending[index]
+			if !ok {
+				break
+			}
+			if p.err != nil {
+				return p.err
+			}
+			delete(pending, index)
+			if err := write(p.result); err != nil {
+				return err
+			}
+			<-tokens
+			index++
+		}
+	}
+	return nil
+}
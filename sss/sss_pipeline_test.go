@@ -0,0 +1,98 @@
+package sss
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestInOrder(t *testing.T) {
+	const jobs = 200
+	index := 0
+	next := func() (int, bool, error) {
+		if index >= jobs {
+			return 0, false, nil
+		}
+		index++
+		return index - 1, true, nil
+	}
+	work := func(j int) (int, error) {
+		// Later jobs finish first.
+		time.Sleep(time.Duration(jobs-j) * time.Microsecond)
+		return j * 2, nil
+	}
+	var written []int
+	write := func(r int) error {
+		written = append(written, r)
+		return nil
+	}
+	discard := func(int) { t.Fatalf("expect no result discarded") }
+	if err := inOrder(next, work, write, discard); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(written) != jobs {
+		t.Fatalf("expect %d results, actual %d", jobs, len(written))
+	}
+	for i, r := range written {
+		if r != i*2 {
+			t.Fatalf("expect result %d at %d, actual %d", i*2, i, r)
+		}
+	}
+}
+
+func TestInOrder_error(t *testing.T) {
+	failure := errors.New("failure")
+	for _, failAt := range []string{"next", "work", "write"} {
+		index := 0
+		next := func() (int, bool, error) {
+			if failAt == "next" && index == 50 {
+				return 0, false, failure
+			}
+			index++
+			return index - 1, true, nil
+		}
+		var worked, written, discarded, running atomic.Int32
+		work := func(j int) (int, error) {
+			running.Add(1)
+			defer running.Add(-1)
+			worked.Add(1)
+			time.Sleep(time.Millisecond)
+			if failAt == "work" && j == 50 {
+				return j + 1, failure
+			}
+			return j + 1, nil
+		}
+		write := func(r int) error {
+			if failAt == "write" && r == 51 {
+				return failure
+			}
+			written.Add(1)
+			return nil
+		}
+		discard := func(r int) {
+			// The error of next comes with no result.
+			if r > 0 {
+				discarded.Add(1)
+			}
+		}
+		if err := inOrder(next, work, write, discard); !errors.Is(err, failure) {
+			t.Fatalf("%s: expect failure, actual %v", failAt, err)
+		}
+		if written.Load() != 50 {
+			t.Fatalf("%s: expect 50 results written, actual %d", failAt, written.Load())
+		}
+		// Every worker is done on return, and every result is either written or discarded.
+		if running.Load() != 0 {
+			t.Fatalf("%s: expect no worker running, actual %d", failAt, running.Load())
+		}
+		lost := int32(0)
+		if failAt == "write" {
+			lost = 1 // the result write failed on
+		}
+		if worked.Load() != written.Load()+discarded.Load()+lost {
+			t.Fatalf("%s: %d results, %d written and %d discarded", failAt, worked.Load(), written.Load(),
+				discarded.Load())
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package sss
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/wangkang/fortify/utils"
+)
+
+// Reshare reconstructs the secret from at least threshold parts and splits it again into a new
+// set of parts with fresh random polynomials. The new parts cannot be combined with the old ones,
+// but keep the same secret digest, so data protected by the old parts opens with the new ones.
+// Zero newParts or newThreshold keep the values of the old parts.
+func Reshare(parts []Part, newParts, newThreshold uint8) ([]Part, error) {
+	if len(parts) == 0 {
+		return nil, errors.New("no secret shares to reshare")
+	}
+	first := parts[0]
+	if err := unsupported(parts, "resharing", kindSsss, kindVerifiable, kindWeighted); err != nil {
+		return nil, err
+	}
+	if totalWeight(parts) < int(first.Threshold) {
+		return nil, fmt.Errorf("need %d secret shares, got %d", first.Threshold, totalWeight(parts))
+	}
+	if newParts == 0 {
+		newParts = MaxParts(parts)
+	}
+	if newThreshold == 0 {
+		newThreshold = first.Threshold
+	}
+	raw, err := combineRaw(parts)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.Wipe(raw)
+	var secret []byte
+	if secret, err = unblind(parts, raw); err != nil {
+		return nil, err
+	}
+	utils.Wipe(secret)
+	// The blinding bytes are split along with the secret, so the digest stays the same.
+	if len(first.Weights) > 0 {
+		var weights []uint8
+		if weights, err = ParseWeights(first.Weights); err != nil {
+			return nil, err
+		}
+		if int(newParts) != len(weights) {
+			return nil, fmt.Errorf("resharing weighted secret shares keeps their number of %d", len(weights))
+		}
+		return splitWeighted(raw, first.Digest, first.Blinding, weights, newThreshold)
+	}
+	scheme := first.Scheme
+	if scheme == SchemeSsss {
+		// ssss parts are reshared as parts over GF(2^8).
+		scheme = ""
+	}
+	return split(raw, first.Digest, first.Blinding, "", newParts, newThreshold, scheme)
+}
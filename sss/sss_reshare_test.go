@@ -0,0 +1,89 @@
+package sss
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReshare(t *testing.T) {
+	secret := []byte("test reshare")
+	ps, err := Split(secret, 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	reshared, err := Reshare([]Part{ps[2], ps[0]}, 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(reshared) != 5 {
+		t.Fatalf("expect 5 parts, actual %d", len(reshared))
+	}
+	for _, p := range reshared {
+		// The keyed digest survives, under a new set.
+		if p.Parts != 5 || p.Threshold != 3 || p.Digest != ps[0].Digest || p.Blinding != ps[0].Blinding {
+			t.Fatalf("unexpected part %+v", p)
+		}
+		if len(p.Set) == 0 || p.Set == ps[0].Set {
+			t.Fatalf("expect a new set, actual %s", p.Set)
+		}
+	}
+	recombined, err := Combine([]Part{reshared[4], reshared[1], reshared[2]})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recombined, secret) {
+		t.Fatalf("expect %q, actual %q", secret, recombined)
+	}
+	if _, err = Combine(reshared[:2]); err == nil {
+		t.Fatalf("expect error")
+	}
+
+	// Zero keeps the number of parts and the threshold.
+	same, err := Reshare(ps[:2], 0, 0)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(same) != 3 || same[0].Threshold != 2 || same[0].Digest != ps[0].Digest {
+		t.Fatalf("unexpected parts %+v", same)
+	}
+
+	for name, parts := range map[string][]Part{
+		"none":      nil,
+		"too few":   ps[:1],
+		"tampered":  {ps[0], corruptPart(ps[1])},
+		"old reuse": {reshared[0], reshared[1], ps[0]},
+	} {
+		if _, err = Reshare(parts, 0, 0); err == nil {
+			t.Fatalf("%s: expect error", name)
+		}
+	}
+}
+
+func TestReshare_mixed(t *testing.T) {
+	secret := []byte("test reshare")
+	ps, err := Split(secret, 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	reshared, err := Reshare(ps[:2], 0, 0)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// Old and new shares lie on different polynomials, and are refused together.
+	for _, parts := range [][]Part{{ps[0], reshared[1]}, {reshared[2], ps[1]}, {ps[2], reshared[0]}} {
+		if recombined, err := Combine(parts); err == nil {
+			t.Fatalf("expect error, actual %q", recombined)
+		}
+	}
+}
+
+// corruptPart returns a copy of the part whose share is of another secret.
+func corruptPart(p Part) Part {
+	others, err := Split([]byte("TEST RESHARE"), p.Parts, p.Threshold)
+	if err != nil {
+		panic(err)
+	}
+	q := p
+	q.Payload = others[p.Part-1].Payload
+	return q
+}
@@ -0,0 +1,254 @@
+package sss
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/wangkang/fortify/shamir"
+	"github.com/wangkang/fortify/utils"
+)
+
+// maxRobustAttempts bounds the number of subsets of parts CombineRobust tries.
+const maxRobustAttempts = 100000
+
+// CombineRobust reconstructs the secret like Combine, but tolerates corrupted or forged parts
+// among more parts than the threshold. It tries the subsets of parts meeting the threshold, fewest
+// parts first, until one reconstructs a secret matching the digest, and returns the secret along
+// with the indexes of the parts inconsistent with it. Parts without a digest are trusted only if
+// more parts than the threshold agree on the secret.
+func CombineRobust(parts []Part) (secret []byte, bad []int, err error) {
+	if len(parts) == 0 {
+		return nil, nil, errors.New("no secret shares to combine")
+	}
+	if err = unsupported(parts, "robust combining", kindVerifiable, kindWeighted, kindBlocks); err != nil {
+		return nil, nil, err
+	}
+	if err = checkVerifiable(parts); err != nil {
+		return nil, nil, err
+	}
+	if publishedCommitments() != nil {
+		// Every part is checked against the published commitments, whichever commitments it carries.
+		candidates := make([]int, len(parts))
+		for i := range parts {
+			candidates[i] = i
+		}
+		return combineRobustVerifiable(parts, candidates, nil)
+	}
+	candidates, bad := agreeingParts(parts)
+	first := parts[candidates[0]]
+	weight := 0
+	for _, i := range candidates {
+		weight += parts[i].Weight()
+	}
+	if weight < int(first.Threshold) {
+		return nil, nil, fmt.Errorf("need %d consistent secret shares, got %d", first.Threshold, weight)
+	}
+	if first.Scheme == SchemeFeldman {
+		return combineRobustVerifiable(parts, candidates, bad)
+	}
+	shares := make([][][]byte, len(parts))
+	var good []int
+	for _, i := range candidates {
+		if shares[i], err = partShares(parts[i]); err != nil {
+			bad = append(bad, i)
+		} else {
+			good = append(good, i)
+		}
+	}
+	candidates = good
+	var best []int
+	var bestRaw []byte
+	defer func() { utils.Wipe(bestRaw) }()
+	attempts := 0
+	for k := 1; k <= len(candidates) && attempts < maxRobustAttempts && !(best != nil && len(first.Digest) > 0); k++ {
+		forEachCombination(len(candidates), k, func(subset []int) bool {
+			if attempts++; attempts > maxRobustAttempts {
+				return false
+			}
+			var basis [][]byte
+			for _, i := range subset {
+				basis = append(basis, shares[candidates[i]]...)
+			}
+			if len(basis) < int(first.Threshold) {
+				return true
+			}
+			basis = basis[:first.Threshold]
+			raw, err := shamir.Combine(basis)
+			if err != nil {
+				return true
+			}
+			var consistent []int
+			for _, i := range candidates {
+				if sharesConsistent(basis, shares[i]) {
+					consistent = append(consistent, i)
+				}
+			}
+			if len(first.Digest) > 0 {
+				if first.Blinding <= len(raw) && secretDigest(first, raw) == first.Digest &&
+					authenticateSet(pick(parts, consistent), raw) == nil {
+					best, bestRaw = consistent, raw
+					return false
+				}
+			} else if len(consistent) > len(best) {
+				utils.Wipe(bestRaw)
+				best, bestRaw = consistent, raw
+				return true
+			}
+			utils.Wipe(raw)
+			return true
+		})
+	}
+	if best == nil {
+		return nil, nil, errors.New("no subset of the secret shares reconstructs the secret, more shares are needed to outvote the corrupted ones")
+	}
+	if len(first.Digest) == 0 && totalWeight(pick(parts, best)) <= int(first.Threshold) {
+		return nil, nil, errors.New("secret shares without a digest need more than the threshold to agree on the secret")
+	}
+	for _, i := range candidates {
+		if !slices.Contains(best, i) {
+			bad = append(bad, i)
+		}
+	}
+	if secret, err = unblind(pick(parts, best), bestRaw); err != nil {
+		return nil, nil, err
+	}
+	slices.Sort(bad)
+	return secret, bad, nil
+}
+
+// combineRobustVerifiable checks every verifiable part against the published commitments, or else
+// against the commitments carried by most of the parts, and combines the parts passing the check.
+func combineRobustVerifiable(parts []Part, candidates, bad []int) ([]byte, []int, error) {
+	var good []int
+	var shares [][]byte
+	for _, i := range candidates {
+		share, err := base64.URLEncoding.DecodeString(parts[i].Payload)
+		if err == nil {
+			err = verifyShares([]Part{parts[i]}, [][]byte{share})
+		}
+		if err != nil || len(parts[i].Points) > 0 {
+			bad = append(bad, i)
+			continue
+		}
+		good = append(good, i)
+		shares = append(shares, share)
+	}
+	threshold := int(parts[candidates[0]].Threshold)
+	if c := publishedCommitments(); c != nil {
+		threshold = len(c.Commitments)
+	}
+	if len(good) < threshold {
+		return nil, nil, fmt.Errorf("need %d valid secret shares, got %d", threshold, len(good))
+	}
+	raw, err := shamir.CombineVerifiable(shares)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer utils.Wipe(raw)
+	var secret []byte
+	if secret, err = unblind(pick(parts, good), raw); err != nil {
+		return nil, nil, err
+	}
+	slices.Sort(bad)
+	return secret, bad, nil
+}
+
+// agreeingParts returns the indexes of the parts sharing the set, threshold, digest and scheme
+// carried by most of the parts, and of the others.
+func agreeingParts(parts []Part) (agreeing, others []int) {
+	key := func(p Part) string {
+		return fmt.Sprintf("%s\x00%d\x00%s\x00%d\x00%s\x00%s\x00%s", p.Set, p.Threshold, p.Digest, p.Blinding,
+			p.Scheme, p.Weights, strings.Join(p.Commitments, ","))
+	}
+	counts := make(map[string]int)
+	var reference string
+	for _, p := range parts {
+		k := key(p)
+		counts[k]++
+		if counts[k] > counts[reference] {
+			reference = k
+		}
+	}
+	for i, p := range parts {
+		if key(p) == reference {
+			agreeing = append(agreeing, i)
+		} else {
+			others = append(others, i)
+		}
+	}
+	return
+}
+
+// partShares decodes the share points of a part.
+func partShares(p Part) ([][]byte, error) {
+	var shares [][]byte
+	for _, payload := range append([]string{p.Payload}, p.Points...) {
+		share, err := base64.URLEncoding.DecodeString(payload)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+	return shares, nil
+}
+
+// sharesConsistent reports whether the shares lie on the polynomial interpolated by the basis.
+func sharesConsistent(basis, shares [][]byte) bool {
+	for _, share := range shares {
+		if len(share) != len(basis[0]) || len(share) < 2 {
+			return false
+		}
+		x := share[len(share)-1]
+		found := false
+		for _, b := range basis {
+			if b[len(b)-1] == x {
+				if !bytes.Equal(b, share) {
+					return false
+				}
+				found = true
+			}
+		}
+		if found {
+			continue
+		}
+		expect, err := shamir.Extend(basis, x)
+		if err != nil || !bytes.Equal(expect, share) {
+			return false
+		}
+	}
+	return true
+}
+
+// forEachCombination calls fn with the subsets of k of the indexes [0, n) in lexicographic order,
+// until fn returns false.
+func forEachCombination(n, k int, fn func([]int) bool) {
+	subset := make([]int, k)
+	for i := range subset {
+		subset[i] = i
+	}
+	for fn(subset) {
+		i := k - 1
+		for i >= 0 && subset[i] == n-k+i {
+			i--
+		}
+		if i < 0 {
+			return
+		}
+		subset[i]++
+		for j := i + 1; j < k; j++ {
+			subset[j] = subset[j-1] + 1
+		}
+	}
+}
+
+func pick(parts []Part, indexes []int) []Part {
+	picked := make([]Part, len(indexes))
+	for i, index := range indexes {
+		picked[i] = parts[index]
+	}
+	return picked
+}
@@ -0,0 +1,169 @@
+package sss
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"slices"
+	"testing"
+)
+
+// corrupt changes the share of a part at random, keeping its x coordinate. The changes of two
+// parts do not cancel out when both are combined.
+func corrupt(t *testing.T, p Part) Part {
+	t.Helper()
+	share, err := base64.URLEncoding.DecodeString(p.Payload)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	noise := make([]byte, len(share)-1)
+	if _, err = rand.Read(noise); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	noise[0] |= 1
+	for i, b := range noise {
+		share[i] ^= b
+	}
+	p.Payload = base64.URLEncoding.EncodeToString(share)
+	return p
+}
+
+func TestCombineRobust(t *testing.T) {
+	secret := []byte("test robust")
+	ps, err := Split(secret, 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	parts := slices.Clone(ps)
+	parts[1] = corrupt(t, parts[1])
+	parts[3] = corrupt(t, parts[3])
+	if _, err = Combine(parts); err == nil {
+		t.Fatalf("expect error")
+	}
+	recombined, bad, err := CombineRobust(parts)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recombined, secret) {
+		t.Fatalf("expect %q, actual %q", secret, recombined)
+	}
+	if !slices.Equal(bad, []int{1, 3}) {
+		t.Fatalf("expect bad [1 3], actual %v", bad)
+	}
+
+	// A share of another secret, under the metadata of the set
+	others, err := Split([]byte("test forged"), 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	forged := slices.Clone(ps)
+	forged[0].Payload = others[0].Payload
+	if recombined, bad, err = CombineRobust(forged); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recombined, secret) || !slices.Equal(bad, []int{0}) {
+		t.Fatalf("expect %q and bad [0], actual %q and %v", secret, recombined, bad)
+	}
+
+	// A part of another set is left out before the subsets are tried.
+	mixed := append(slices.Clone(ps[:3]), others[3])
+	if recombined, bad, err = CombineRobust(mixed); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recombined, secret) || !slices.Equal(bad, []int{3}) {
+		t.Fatalf("expect %q and bad [3], actual %q and %v", secret, recombined, bad)
+	}
+
+	// Too many corrupted parts to reach the threshold
+	parts = slices.Clone(ps[:4])
+	parts[0] = corrupt(t, parts[0])
+	parts[2] = corrupt(t, parts[2])
+	if _, _, err = CombineRobust(parts); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestCombineRobust_noDigest(t *testing.T) {
+	secret := []byte("test robust")
+	ps, err := Split(secret, 5, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := range ps {
+		ps[i].Digest = ""
+	}
+	parts := slices.Clone(ps)
+	parts[2] = corrupt(t, parts[2])
+	recombined, bad, err := CombineRobust(parts)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recombined, secret) || !slices.Equal(bad, []int{2}) {
+		t.Fatalf("expect %q and bad [2], actual %q and %v", secret, recombined, bad)
+	}
+
+	// Without a digest, parts meeting just the threshold cannot outvote anything.
+	parts = []Part{ps[0], corrupt(t, ps[1]), ps[3]}
+	if _, _, err = CombineRobust(parts); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestCombineRobust_verifiable(t *testing.T) {
+	secret := []byte("test robust")
+	ps, err := SplitVerifiable(secret, 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	parts := slices.Clone(ps)
+	parts[4] = corrupt(t, parts[4])
+	recombined, bad, err := CombineRobust(parts)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recombined, secret) || !slices.Equal(bad, []int{4}) {
+		t.Fatalf("expect %q and bad [4], actual %q and %v", secret, recombined, bad)
+	}
+}
+
+func TestCombineRobust_unsupported(t *testing.T) {
+	if _, _, err := CombineRobust(nil); err == nil {
+		t.Fatalf("expect error")
+	}
+	groups, err := ParseGroups("2of3,1of1")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	gs, err := SplitGroups([]byte("test"), 1, groups)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, _, err = CombineRobust(gs[0]); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestForEachCombination(t *testing.T) {
+	var subsets [][]int
+	forEachCombination(4, 2, func(subset []int) bool {
+		subsets = append(subsets, slices.Clone(subset))
+		return true
+	})
+	expect := [][]int{{0, 1}, {0, 2}, {0, 3}, {1, 2}, {1, 3}, {2, 3}}
+	if len(subsets) != len(expect) {
+		t.Fatalf("expect %v, actual %v", expect, subsets)
+	}
+	for i := range expect {
+		if !slices.Equal(subsets[i], expect[i]) {
+			t.Fatalf("expect %v, actual %v", expect, subsets)
+		}
+	}
+	count := 0
+	forEachCombination(5, 3, func([]int) bool {
+		count++
+		return count < 4
+	})
+	if count != 4 {
+		t.Fatalf("expect 4 subsets, actual %d", count)
+	}
+}
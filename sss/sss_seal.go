@@ -0,0 +1,186 @@
+package sss
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/wangkang/fortify/utils"
+	"golang.org/x/crypto/argon2"
+)
+
+// KdfArgon2id names the key derivation function of sealed parts.
+const KdfArgon2id = "argon2id"
+
+// Argon2id parameters of newly sealed parts, the second recommended option of RFC 9106, and the
+// largest parameters accepted from a sealed part, so that a forged part cannot exhaust the memory.
+const (
+	sealTime      = 3
+	sealMemory    = 64 * 1024
+	sealThreads   = 4
+	maxSealTime   = 64
+	maxSealMemory = 1024 * 1024
+	sealSaltSize  = 16
+	sealKeySize   = 32
+)
+
+// Seal describes how the share points of a part are sealed with a key derived from the
+// passphrase of its holder. Memory is given in KiB.
+type Seal struct {
+	Kdf     string `json:"kdf"`
+	Salt    string `json:"salt"`
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+}
+
+// SealParts seals the share points of every part with the passphrase of its holder, read for each
+// part in turn, so that a copied part file is of no use without the passphrase. It refuses to seal
+// several parts with a passphrase source giving them all the same passphrase.
+func SealParts(ps []Part) error {
+	if len(ps) > 1 && utils.SinglePassphrase() {
+		return errors.New("sealing every secret share with the same passphrase of --passphrase-file or " +
+			"--passphrase-env is refused, give one passphrase per secret share with --passphrase-fd, --askpass or the terminal")
+	}
+	for i := range ps {
+		p := &ps[i]
+		if p.Scheme == SchemeFeldman || p.Scheme == SchemeIda {
+			return fmt.Errorf("secret shares of scheme %q cannot be sealed", p.Scheme)
+		}
+		passphrase, err := utils.ReadNewPassphrase(fmt.Sprintf("Enter passphrase to seal %s: ", describeSealed(*p)))
+		if err != nil {
+			return err
+		}
+		err = sealPart(p, passphrase)
+		utils.Wipe(passphrase)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sealPart(p *Part, passphrase []byte) error {
+	if len(passphrase) == 0 {
+		return errors.New("empty passphrase")
+	}
+	salt := make([]byte, sealSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	seal := &Seal{
+		Kdf:     KdfArgon2id,
+		Salt:    base64.URLEncoding.EncodeToString(salt),
+		Time:    sealTime,
+		Memory:  sealMemory,
+		Threads: sealThreads,
+	}
+	aead, err := newSealCipher(seal, passphrase)
+	if err != nil {
+		return err
+	}
+	points := append([]string{p.Payload}, p.Points...)
+	for i, point := range points {
+		var plain []byte
+		if plain, err = base64.URLEncoding.DecodeString(point); err != nil {
+			return err
+		}
+		sealed := aead.Seal(nil, sealNonce(i), plain, sealAdditionalData(*p))
+		utils.Wipe(plain)
+		points[i] = base64.URLEncoding.EncodeToString(sealed)
+	}
+	p.Payload = points[0]
+	if len(p.Points) > 0 {
+		p.Points = points[1:]
+	}
+	p.Seal = seal
+	return nil
+}
+
+// unsealPart reads the passphrase of a sealed part, and unseals its share points.
+func unsealPart(p *Part, name string) error {
+	passphrase, err := utils.ReadPassphrase(fmt.Sprintf("Enter passphrase of key part %s: ", describePart(name, *p)))
+	if err != nil {
+		return err
+	}
+	defer utils.Wipe(passphrase)
+	var aead cipher.AEAD
+	if aead, err = newSealCipher(p.Seal, passphrase); err != nil {
+		return fmt.Errorf("sealed key part %s: %v", name, err)
+	}
+	points := append([]string{p.Payload}, p.Points...)
+	for i, point := range points {
+		var sealed, plain []byte
+		if sealed, err = base64.URLEncoding.DecodeString(point); err != nil {
+			return err
+		}
+		if plain, err = aead.Open(nil, sealNonce(i), sealed, sealAdditionalData(*p)); err != nil {
+			return fmt.Errorf("wrong passphrase for key part %s", describePart(name, *p))
+		}
+		points[i] = base64.URLEncoding.EncodeToString(plain)
+		utils.Wipe(plain)
+	}
+	p.Payload = points[0]
+	if len(p.Points) > 0 {
+		p.Points = points[1:]
+	}
+	p.Seal = nil
+	return nil
+}
+
+// newSealCipher derives the key of a sealed part from the passphrase of its holder.
+func newSealCipher(seal *Seal, passphrase []byte) (cipher.AEAD, error) {
+	if seal.Kdf != KdfArgon2id {
+		return nil, fmt.Errorf("unsupported key derivation function: %q", seal.Kdf)
+	}
+	if seal.Time == 0 || seal.Time > maxSealTime || seal.Memory == 0 || seal.Memory > maxSealMemory || seal.Threads == 0 {
+		return nil, fmt.Errorf("unsupported %s parameters: time %d, memory %d KiB, threads %d",
+			seal.Kdf, seal.Time, seal.Memory, seal.Threads)
+	}
+	salt, err := base64.URLEncoding.DecodeString(seal.Salt)
+	if err != nil || len(salt) < sealSaltSize {
+		return nil, errors.New("invalid salt")
+	}
+	key := argon2.IDKey(passphrase, salt, seal.Time, seal.Memory, seal.Threads, sealKeySize)
+	defer utils.Wipe(key)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealNonce numbers the share points of a part, which are all sealed with the key of the part.
+func sealNonce(point int) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce[4:], uint64(point))
+	return nonce
+}
+
+// sealAdditionalData binds the sealed share points to their part, so that they cannot be moved
+// into another part sealed with the same passphrase.
+func sealAdditionalData(p Part) []byte {
+	return []byte(fmt.Sprintf("fortify sss seal\x00%s\x00%d\x00%d\x00%d", p.Set, p.Group, p.Part, p.Threshold))
+}
+
+// describeSealed names a part to be sealed by its number, set and holder.
+func describeSealed(p Part) string {
+	s := fmt.Sprintf("part %d", p.Part)
+	if p.Group > 0 {
+		s = fmt.Sprintf("part %d of group %d", p.Part, p.Group)
+	}
+	if len(p.Holder) > 0 {
+		s += " held by " + p.Holder
+	}
+	return s
+}
+
+// isSealedKeyPart reports whether the input is a key part file holding a sealed part.
+func isSealedKeyPart(name string) bool {
+	p, err := readCandidate(name)
+	return err == nil && p.Seal != nil
+}
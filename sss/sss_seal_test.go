@@ -0,0 +1,60 @@
+package sss
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wangkang/fortify/utils"
+)
+
+func TestSealParts_singlePassphrase(t *testing.T) {
+	t.Cleanup(func() { _ = utils.SetPassphraseSource(utils.PassphraseSource{Fd: -1}) })
+	path := filepath.Join(t.TempDir(), "passphrase")
+	if err := os.WriteFile(path, []byte("same for all\n"), 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ps, err := Split([]byte("test"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, source := range []utils.PassphraseSource{{File: path, Fd: -1}, {Env: "PATH", Fd: -1}} {
+		if err = utils.SetPassphraseSource(source); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if err = SealParts(ps); err == nil {
+			t.Fatalf("expect error")
+		}
+	}
+	for _, p := range ps {
+		if p.Seal != nil {
+			t.Fatalf("expect part %d unsealed", p.Part)
+		}
+	}
+
+	// A single part has no other to share its passphrase with.
+	if err = utils.SetPassphraseSource(utils.PassphraseSource{File: path, Fd: -1}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	payload := ps[0].Payload
+	if err = SealParts(ps[:1]); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if ps[0].Seal == nil || ps[0].Payload == payload {
+		t.Fatalf("expect part 1 sealed")
+	}
+	if err = unsealPart(&ps[0], "part 1"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if ps[0].Payload != payload {
+		t.Fatalf("expect payload %s, actual %s", payload, ps[0].Payload)
+	}
+	secret, err := Combine(ps[:2])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(secret, []byte("test")) {
+		t.Fatalf("expect test, actual %q", secret)
+	}
+}
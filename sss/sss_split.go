@@ -1,10 +1,12 @@
 package sss
 
 import (
-	"bufio"
+	"crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"sync"
@@ -16,29 +18,101 @@ import (
 )
 
 func Split(secret []byte, parts, threshold uint8) ([]Part, error) {
+	raw, digest, err := blind(secret)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.Wipe(raw)
+	return split(raw, digest, blindingSize, "", parts, threshold, "")
+}
+
+// SplitVerifiable splits the secret into parts carrying Feldman commitments, against which
+// every holder can check their own part, and the combiner can single out a bad part.
+func SplitVerifiable(secret []byte, parts, threshold uint8) ([]Part, error) {
+	if size := shamir.FeldmanMaxSecretSize - blindingSize; len(secret) > size {
+		return nil, fmt.Errorf("verifiable secret cannot exceed %d bytes", size)
+	}
+	raw, digest, err := blind(secret)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.Wipe(raw)
+	return split(raw, digest, blindingSize, "", parts, threshold, SchemeFeldman)
+}
+
+// blind appends random blinding bytes to the secret, and computes the digest of the secret
+// keyed with them.
+func blind(secret []byte) (raw []byte, digest string, err error) {
+	raw = make([]byte, len(secret)+blindingSize)
+	copy(raw, secret)
+	if _, err = rand.Read(raw[len(secret):]); err != nil {
+		return nil, "", err
+	}
+	return raw, utils.ComputeKeyedDigest(raw[len(secret):], secret), nil
+}
+
+// split splits the raw secret, which carries blinding bytes of the given size, with the scheme into
+// parts of the set, or of a new set if set is empty.
+func split(raw []byte, digest string, blinding int, set string, parts, threshold uint8, scheme string) ([]Part, error) {
+	var out, commitments [][]byte
 	var err error
-	var out [][]byte
-	var outParts []Part
-	out, err = shamir.Split(secret, int(parts), int(threshold))
+	if scheme == SchemeFeldman {
+		out, commitments, err = shamir.SplitVerifiable(raw, int(parts), int(threshold))
+	} else {
+		out, err = shamir.Split(raw, int(parts), int(threshold))
+	}
 	if err != nil {
-		return outParts, err
+		return nil, err
+	}
+	if len(set) == 0 {
+		if set, err = newSetId(); err != nil {
+			return nil, err
+		}
 	}
-	digest := utils.ComputeDigest(secret)
+	coordinates := make([]byte, len(out))
+	for index, i := range out {
+		coordinates[index] = i[len(i)-1]
+	}
+	var encoded []string
+	for _, c := range commitments {
+		encoded = append(encoded, base64.URLEncoding.EncodeToString(c))
+	}
+	key := setMacKey(raw)
+	outParts := make([]Part, 0, len(out))
 	for index, i := range out {
 		p := Part{
-			Parts:     parts,
-			Part:      index + 1,
-			Payload:   base64.URLEncoding.EncodeToString(i),
-			Timestamp: time.Now(),
-			Threshold: threshold,
-			Digest:    digest,
+			Parts:       parts,
+			Part:        index + 1,
+			Payload:     base64.URLEncoding.EncodeToString(i),
+			Timestamp:   time.Now(),
+			Threshold:   threshold,
+			Digest:      digest,
+			Blinding:    blinding,
+			Set:         set,
+			Coordinates: hex.EncodeToString(coordinates),
+			Scheme:      scheme,
+			Commitments: encoded,
 		}
+		p.SetMac = setMac(key, p)
 		outParts = append(outParts, p)
 	}
 	return outParts, nil
 }
 
-func SplitIntoFiles(in string, parts, threshold uint8, prefix string, truncate, verbose bool) error {
+// newSetId returns a random identifier shared by all parts of one split.
+func newSetId() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// SplitIntoFiles splits the input file block by block into the files of the parts. With ida, every
+// part holds about the size of the file / threshold instead of the size of the file, see SchemeIda.
+// Blocks are split in parallel and written in order, and <prefix>checkpoint.json records the blocks
+// written, so that an interrupted split can go on from there with resume.
+func SplitIntoFiles(in string, parts, threshold uint8, prefix string, ida, resume, truncate, verbose bool) error {
 	file, closer, err := files.OpenInputFile(in)
 	if err != nil {
 		return err
@@ -49,34 +123,110 @@ func SplitIntoFiles(in string, parts, threshold uint8, prefix string, truncate,
 		return err
 	}
 	blocks := int(math.Ceil(float64(stat.Size()) / float64(fileBlockSize)))
-	reader := bufio.NewReader(file)
-	buffer := make([]byte, fileBlockSize)
-	var bytesRead, block int
-	var ps []Part
-	for {
-		bytesRead, err = reader.Read(buffer)
-		if err != nil {
+	cp := &checkpoint{
+		Size:      stat.Size(),
+		ModTime:   stat.ModTime(),
+		Parts:     parts,
+		Threshold: threshold,
+		Ida:       ida,
+		Blocks:    blocks,
+		Lengths:   make([]int64, parts),
+		path:      prefix + "checkpoint.json",
+	}
+	if cp.Inputs, err = absPaths(in); err != nil {
+		return err
+	}
+	paths := make([]string, parts)
+	for i := range paths {
+		paths[i] = fmt.Sprintf("%s%dof%d.json", prefix, i+1, parts)
+	}
+	var splitBlock func(block int, data []byte) ([]Part, error)
+	if resume {
+		var saved *checkpoint
+		if saved, err = loadCheckpoint(cp.path); err != nil {
 			return err
 		}
-		secret := buffer[:bytesRead]
-		ps, err = Split(secret, parts, threshold)
-		if err != nil {
+		if !saved.matches(cp) {
+			return fmt.Errorf("checkpoint %s does not match the input file and the flags", cp.path)
+		}
+		cp.Set, cp.Done, cp.Lengths = saved.Set, saved.Done, saved.Lengths
+		if ida && cp.Done > 0 {
+			if splitBlock, err = resumeIdaSplitter(paths, blocks); err != nil {
+				return err
+			}
+		}
+		for i, path := range paths {
+			if err = resumeFileForWrite(path, cp.Lengths[i]); err != nil {
+				return err
+			}
+		}
+		if _, err = file.Seek(int64(cp.Done)*fileBlockSize, io.SeekStart); err != nil {
 			return err
 		}
-		err = AppendParts(ps, block, blocks, prefix, truncate)
-		if err != nil {
+		if verbose {
+			fmt.Printf("Resume from block %d/%d\n", cp.Done+1, blocks)
+		}
+	} else if cp.Set, err = newSetId(); err != nil {
+		return err
+	}
+	if splitBlock == nil && ida {
+		if splitBlock, err = newIdaSplitter(cp.Set, parts, threshold, blocks); err != nil {
 			return err
 		}
+	} else if splitBlock == nil {
+		splitBlock = func(_ int, data []byte) ([]Part, error) {
+			raw, digest, err := blind(data)
+			if err != nil {
+				return nil, err
+			}
+			defer utils.Wipe(raw)
+			return split(raw, digest, blindingSize, cp.Set, parts, threshold, "")
+		}
+	}
+	if err = cp.save(); err != nil {
+		return err
+	}
+	type splitJob struct {
+		block int
+		data  []byte
+	}
+	block := cp.Done
+	next := func() (splitJob, bool, error) {
+		if block >= blocks {
+			return splitJob{}, false, nil
+		}
+		data := make([]byte, min(fileBlockSize, stat.Size()-int64(block)*fileBlockSize))
+		if _, err := io.ReadFull(file, data); err != nil {
+			return splitJob{}, false, err
+		}
 		block++
+		return splitJob{block - 1, data}, true, nil
+	}
+	work := func(j splitJob) ([]Part, error) {
+		defer utils.Wipe(j.data)
+		return splitBlock(j.block, j.data)
+	}
+	write := func(ps []Part) error {
+		if err := AppendParts(ps, cp.Done, blocks, prefix, truncate); err != nil {
+			return err
+		}
+		outputs := make([]*os.File, len(ps))
+		for i, p := range ps {
+			outputs[i] = p.file
+		}
+		if err := cp.update(outputs); err != nil {
+			return err
+		}
 		if verbose {
 			w := len(fmt.Sprintf("%d", blocks))
-			fmt.Printf("Block %*d/%d OK\n", w, block, blocks)
-		}
-		if bytesRead < fileBlockSize {
-			break
+			fmt.Printf("Block %*d/%d OK\n", w, cp.Done, blocks)
 		}
+		return nil
 	}
-	return nil
+	if err = inOrder(next, work, write, nil); err != nil {
+		return err
+	}
+	return cp.remove()
 }
 
 func AppendParts(ps []Part, block, blocks int, prefix string, truncate bool) error {
@@ -123,6 +273,7 @@ func appendPart(p *Part, block int) (err error) {
 			return
 		}
 	}
+	signPart(p)
 	var content []byte
 	content, err = json.Marshal(p)
 	if err != nil {
@@ -162,6 +313,23 @@ func OpenFileForWrite(path string, truncate bool) (*os.File, error) {
 	return file, nil
 }
 
+// resumeFileForWrite opens a file written up to size bytes by an interrupted split, to be returned
+// by OpenFileForWrite.
+func resumeFileForWrite(path string, size int64) error {
+	openedFilesForWriteLock.Lock()
+	defer openedFilesForWriteLock.Unlock()
+	if openedFilesForWrite[path] != nil {
+		return nil
+	}
+	file, closer, err := files.ResumeOutputFile(path, size)
+	if err != nil {
+		return err
+	}
+	openedFilesForWrite[path] = file
+	openedFilesForWriteCloser[path] = closer
+	return nil
+}
+
 func CloseAllFilesForWrite() {
 	openedFilesForWriteLock.Lock()
 	defer openedFilesForWriteLock.Unlock()
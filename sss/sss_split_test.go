@@ -0,0 +1,90 @@
+package sss
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/wangkang/fortify/utils"
+)
+
+func TestSplit_digest(t *testing.T) {
+	secret := []byte("test keyed digest")
+	ps, err := Split(secret, 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	recombined, err := Combine(ps[1:])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recombined, secret) {
+		t.Fatalf("expect %q, actual %q", secret, recombined)
+	}
+
+	// A part whose share is altered reconstructs another secret, which fails the digest check.
+	tampered := ps[1]
+	share, err := base64.URLEncoding.DecodeString(tampered.Payload)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	share[0] ^= 0x01
+	tampered.Payload = base64.URLEncoding.EncodeToString(share)
+	if _, err = Combine([]Part{ps[0], tampered}); err == nil || err.Error() != "secret digest mismatch" {
+		t.Fatalf("expect secret digest mismatch, actual %v", err)
+	}
+	// So does a set of parts carrying another digest.
+	for i := range ps {
+		ps[i].Digest = utils.ComputeDigest(secret)
+	}
+	if _, err = Combine(ps[:2]); err == nil || err.Error() != "secret digest mismatch" {
+		t.Fatalf("expect secret digest mismatch, actual %v", err)
+	}
+}
+
+func TestSplit_digestBlinded(t *testing.T) {
+	secret := []byte("test keyed digest")
+	ps, err := Split(secret, 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	others, err := Split(secret, 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	digest := ps[0].Digest
+	if ps[0].Blinding != blindingSize {
+		t.Fatalf("expect %d blinding bytes, actual %d", blindingSize, ps[0].Blinding)
+	}
+	// Splitting the same secret twice gives unrelated digests, neither being a plain hash of it.
+	if digest == others[0].Digest {
+		t.Fatalf("expect another digest of the same secret, actual %s", digest)
+	}
+	for _, d := range []string{digest, others[0].Digest} {
+		if d == utils.ComputeDigest(secret) {
+			t.Fatalf("expect a digest keyed with the blinding, actual the plain digest")
+		}
+	}
+	// A guessed secret cannot be confirmed with a guessed blinding, only with the one in the shares.
+	for name, key := range map[string][]byte{
+		"no blinding":    nil,
+		"zero blinding":  make([]byte, blindingSize),
+		"secret as key":  secret,
+		"other blinding": bytes.Repeat([]byte{0xff}, blindingSize),
+	} {
+		if utils.ComputeKeyedDigest(key, secret) == digest {
+			t.Fatalf("%s: expect the digest not confirmed", name)
+		}
+	}
+	raw, err := combineRaw(ps[:2])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if secretDigest(ps[0], raw) != digest {
+		t.Fatalf("expect the digest confirmed with the blinding of the shares")
+	}
+	// A single part does not carry the blinding.
+	if _, err = combineRaw(ps[:1]); err == nil {
+		t.Fatalf("expect error")
+	}
+}
@@ -0,0 +1,126 @@
+package sss
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wangkang/fortify/shamir"
+	"github.com/wangkang/fortify/utils"
+)
+
+// ParseSsssShares parses shares written by ssss-split, one [token-]index-hex per line.
+func ParseSsssShares(content string) ([]string, error) {
+	var shares []string
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); len(line) > 0 {
+			shares = append(shares, line)
+		}
+	}
+	if len(shares) == 0 {
+		return nil, errors.New("no ssss shares found")
+	}
+	return shares, nil
+}
+
+// ImportSsss turns ssss shares into parts numbered by the index of the shares, with neither set nor
+// digest, which is filled in when enough parts are combined. The threshold is not written in
+// ssss shares, and must be given as to ssss-combine.
+func ImportSsss(shares []string, parts, threshold uint8, diffusion bool) ([]Part, error) {
+	if threshold < 2 || threshold > parts {
+		return nil, fmt.Errorf("invalid ssss threshold %d of %d shares", threshold, parts)
+	}
+	seen := make(map[int]bool)
+	out := make([]Part, len(shares))
+	for i, share := range shares {
+		cut := strings.LastIndexByte(share, '-')
+		if cut < 0 {
+			return nil, fmt.Errorf("ssss share %d is not written as index-hex", i+1)
+		}
+		index, err := strconv.Atoi(share[strings.LastIndexByte(share[:cut], '-')+1 : cut])
+		if err != nil || index < 1 || index > int(parts) {
+			return nil, fmt.Errorf("ssss share %d has an invalid index, expect 1 to %d", i+1, parts)
+		}
+		if seen[index] {
+			return nil, fmt.Errorf("ssss share %d is given twice", index)
+		}
+		seen[index] = true
+		var y []byte
+		if y, err = hex.DecodeString(share[cut+1:]); err != nil || len(y) == 0 || len(y) > shamir.SsssMaxSecretSize {
+			return nil, fmt.Errorf("ssss share %d has an invalid security level", index)
+		}
+		if i > 0 && base64.URLEncoding.EncodedLen(len(y)) != len(out[0].Payload) {
+			return nil, fmt.Errorf("ssss share %d differs in security level from the first share", index)
+		}
+		out[i] = Part{
+			Payload:   base64.URLEncoding.EncodeToString(y),
+			Block:     1,
+			Blocks:    1,
+			Part:      index,
+			Parts:     parts,
+			Threshold: threshold,
+			Scheme:    SchemeSsss,
+			Diffusion: diffusion,
+			Timestamp: time.Now(),
+		}
+	}
+	return out, nil
+}
+
+// ExportSsss reconstructs the secret of the parts, and splits it again as ssss-split does, with a
+// security level of 8 bits per byte of the secret, into shares written as index-hex.
+func ExportSsss(parts []Part, diffusion bool) ([]string, error) {
+	if len(parts) == 0 {
+		return nil, errors.New("no secret shares to export")
+	}
+	first := parts[0]
+	if len(first.Groups) > 0 || len(first.Weights) > 0 {
+		return nil, errors.New("group and weighted secret shares cannot be exported to ssss")
+	}
+	if first.Scheme == SchemeIda || first.Blocks > 1 {
+		return nil, errors.New("only secrets of one block can be exported to ssss")
+	}
+	secret, err := Combine(parts)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.Wipe(secret)
+	var ys [][]byte
+	if ys, err = shamir.SplitSsss(secret, int(first.Parts), int(first.Threshold), diffusion); err != nil {
+		return nil, err
+	}
+	width := len(strconv.Itoa(len(ys)))
+	shares := make([]string, len(ys))
+	for i, y := range ys {
+		shares[i] = fmt.Sprintf("%0*d-%s", width, i+1, hex.EncodeToString(y))
+	}
+	return shares, nil
+}
+
+// combineSsss reconstructs the secret from the parts of ssss.
+func combineSsss(parts []Part) ([]byte, error) {
+	first := parts[0]
+	if len(parts) < int(first.Threshold) {
+		return nil, fmt.Errorf("need %d secret shares, got %d", first.Threshold, len(parts))
+	}
+	xs := make([]int, len(parts))
+	ys := make([][]byte, len(parts))
+	for i, p := range parts {
+		if p.Scheme != SchemeSsss || p.Diffusion != first.Diffusion {
+			return nil, fmt.Errorf("secret sharing scheme mismatch in file %v: expect %q, actual %q",
+				i+1, SchemeSsss, p.Scheme)
+		}
+		var err error
+		if ys[i], err = base64.URLEncoding.DecodeString(p.Payload); err != nil {
+			return nil, err
+		}
+		xs[i] = p.Part
+	}
+	return shamir.CombineSsss(xs, ys, int(first.Threshold), first.Diffusion)
+}
@@ -0,0 +1,180 @@
+package sss
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wangkang/fortify/shamir"
+	"github.com/wangkang/fortify/utils"
+)
+
+// VaultKeys are the unseal keys of a HashiCorp Vault, as printed by `vault operator init -format=json`.
+// The shares of the shamir package are those of Vault: the y bytes followed by the x coordinate.
+type VaultKeys struct {
+	KeysB64   []string `json:"unseal_keys_b64"`
+	KeysHex   []string `json:"unseal_keys_hex,omitempty"`
+	Shares    uint8    `json:"unseal_shares"`
+	Threshold uint8    `json:"unseal_threshold"`
+	// Numbers holds the number of the part of each unseal key, for printing.
+	Numbers []int `json:"-"`
+}
+
+// ParseVaultKeys parses unseal keys, given as the JSON printed by `vault operator init -format=json`,
+// as its text output with "Unseal Key N: " lines, or as one base64 or hex key per line. The shares
+// and threshold are zero unless given by the JSON.
+func ParseVaultKeys(content string) (VaultKeys, error) {
+	var keys VaultKeys
+	if strings.HasPrefix(strings.TrimSpace(content), "{") {
+		if err := json.Unmarshal([]byte(content), &keys); err != nil {
+			return keys, fmt.Errorf("not a valid vault operator init output\nCaused by: %v", err)
+		}
+		if len(keys.KeysB64) == 0 {
+			keys.KeysB64, keys.KeysHex = keys.KeysHex, nil
+		}
+		if len(keys.KeysB64) == 0 {
+			return keys, errors.New("no unseal keys in the vault operator init output")
+		}
+		return keys, nil
+	}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "Unseal Key ") {
+			if _, key, ok := strings.Cut(line, ":"); ok {
+				keys.KeysB64 = append(keys.KeysB64, strings.TrimSpace(key))
+			}
+		} else if len(line) > 0 && !strings.Contains(line, " ") {
+			keys.KeysB64 = append(keys.KeysB64, line)
+		}
+	}
+	if len(keys.KeysB64) == 0 {
+		return keys, errors.New("no unseal keys found")
+	}
+	return keys, nil
+}
+
+// ImportVault turns unseal keys into unblinded parts numbered in order, with neither set nor
+// digest, which is filled in when enough parts are combined.
+func ImportVault(keys []string, parts, threshold uint8) ([]Part, error) {
+	if len(keys) > int(parts) {
+		return nil, fmt.Errorf("%d unseal keys given for %d unseal shares", len(keys), parts)
+	}
+	if threshold < 2 || threshold > parts {
+		return nil, fmt.Errorf("invalid unseal threshold %d of %d shares", threshold, parts)
+	}
+	seen := make(map[byte]int)
+	size := 0
+	out := make([]Part, len(keys))
+	for i, key := range keys {
+		share, err := decodeVaultKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("unseal key %d: %v", i+1, err)
+		}
+		if len(share) < 2 {
+			return nil, fmt.Errorf("unseal key %d is too short", i+1)
+		}
+		if i == 0 {
+			size = len(share)
+		} else if len(share) != size {
+			return nil, fmt.Errorf("unseal key %d differs in length from unseal key 1", i+1)
+		}
+		x := share[len(share)-1]
+		if x == 0 {
+			return nil, fmt.Errorf("unseal key %d has an invalid x coordinate", i+1)
+		}
+		if j, ok := seen[x]; ok {
+			return nil, fmt.Errorf("unseal keys %d and %d are the same share", j+1, i+1)
+		}
+		seen[x] = i
+		out[i] = Part{
+			Payload:   base64.URLEncoding.EncodeToString(share),
+			Block:     1,
+			Blocks:    1,
+			Part:      i + 1,
+			Parts:     parts,
+			Threshold: threshold,
+			Timestamp: time.Now(),
+		}
+	}
+	return out, nil
+}
+
+// decodeVaultKey decodes an unseal key written in hex, as in unseal_keys_hex, or in base64.
+func decodeVaultKey(key string) ([]byte, error) {
+	if share, err := hex.DecodeString(key); err == nil {
+		return share, nil
+	}
+	share, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, errors.New("neither hex nor base64")
+	}
+	return share, nil
+}
+
+// ExportVault turns parts into unseal keys. Unblinded parts, such as parts imported from Vault,
+// hold shares of the secret alone and are exported as they are. The shares of blinded parts would
+// reconstruct the secret with its blinding bytes, which does not unseal Vault: as for ssss, they
+// are combined, and the bare secret is split anew into the unseal keys of all the parts.
+func ExportVault(parts []Part, names []string) (VaultKeys, error) {
+	if len(parts) > 0 && parts[0].Blinding > 0 {
+		return splitVault(parts)
+	}
+	var keys VaultKeys
+	for i, p := range parts {
+		switch {
+		case p.Blinding > 0:
+			return keys, fmt.Errorf("key part %s is blinded, and cannot be exported along with unblinded key parts",
+				describePart(names[i], p))
+		case len(p.Scheme) > 0 || len(p.Groups) > 0 || len(p.Points) > 0:
+			return keys, fmt.Errorf("key part %s is not a plain secret share, and cannot be a Vault unseal key",
+				describePart(names[i], p))
+		case p.Blocks > 1:
+			return keys, fmt.Errorf("key part %s has %d blocks, not one", describePart(names[i], p), p.Blocks)
+		}
+		share, err := base64.URLEncoding.DecodeString(p.Payload)
+		if err != nil {
+			return keys, err
+		}
+		keys.KeysB64 = append(keys.KeysB64, base64.StdEncoding.EncodeToString(share))
+		keys.KeysHex = append(keys.KeysHex, hex.EncodeToString(share))
+		keys.Numbers = append(keys.Numbers, p.Part)
+		keys.Shares, keys.Threshold = p.Parts, p.Threshold
+	}
+	return keys, nil
+}
+
+// splitVault combines blinded parts, and splits the secret without its blinding bytes into the
+// unseal keys of all the parts.
+func splitVault(parts []Part) (VaultKeys, error) {
+	var keys VaultKeys
+	first := parts[0]
+	if len(first.Groups) > 0 || len(first.Weights) > 0 {
+		return keys, errors.New("group and weighted secret shares cannot be exported to Vault")
+	}
+	if first.Scheme == SchemeIda || first.Blocks > 1 {
+		return keys, errors.New("only secrets of one block can be exported to Vault")
+	}
+	secret, err := Combine(parts)
+	if err != nil {
+		return keys, err
+	}
+	defer utils.Wipe(secret)
+	var shares [][]byte
+	if shares, err = shamir.Split(secret, int(first.Parts), int(first.Threshold)); err != nil {
+		return keys, err
+	}
+	for i, share := range shares {
+		keys.KeysB64 = append(keys.KeysB64, base64.StdEncoding.EncodeToString(share))
+		keys.KeysHex = append(keys.KeysHex, hex.EncodeToString(share))
+		keys.Numbers = append(keys.Numbers, i+1)
+		utils.Wipe(share)
+	}
+	keys.Shares, keys.Threshold = first.Parts, first.Threshold
+	return keys, nil
+}
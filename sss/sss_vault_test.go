@@ -0,0 +1,244 @@
+package sss
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/wangkang/fortify/shamir"
+)
+
+// vaultKeys splits a secret as Vault does, into unseal keys in base64 and hex.
+func vaultKeys(t *testing.T, secret []byte, parts, threshold int) (b64, hexKeys []string) {
+	t.Helper()
+	shares, err := shamir.Split(secret, parts, threshold)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, share := range shares {
+		b64 = append(b64, base64.StdEncoding.EncodeToString(share))
+		hexKeys = append(hexKeys, hex.EncodeToString(share))
+	}
+	return
+}
+
+func TestParseVaultKeys(t *testing.T) {
+	b64, hexKeys := vaultKeys(t, []byte("test vault"), 5, 3)
+	content := fmt.Sprintf(`{"unseal_keys_b64":["%s"],"unseal_keys_hex":["%s"],"unseal_shares":5,"unseal_threshold":3}`,
+		strings.Join(b64, `","`), strings.Join(hexKeys, `","`))
+	keys, err := ParseVaultKeys(content)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !slices.Equal(keys.KeysB64, b64) || keys.Shares != 5 || keys.Threshold != 3 {
+		t.Fatalf("unexpected keys %+v", keys)
+	}
+
+	// JSON with hex keys only
+	content = fmt.Sprintf(`{"unseal_keys_hex":["%s"],"unseal_shares":5,"unseal_threshold":3}`, strings.Join(hexKeys, `","`))
+	if keys, err = ParseVaultKeys(content); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !slices.Equal(keys.KeysB64, hexKeys) {
+		t.Fatalf("unexpected keys %+v", keys)
+	}
+
+	// Text output of vault operator init, and one key per line
+	var text strings.Builder
+	for i, key := range b64 {
+		_, _ = fmt.Fprintf(&text, "Unseal Key %d: %s\n", i+1, key)
+	}
+	text.WriteString("\nInitial Root Token: hvs.test\n\nVault initialized with 5 key shares and a key threshold of 3.\n")
+	for _, content := range []string{text.String(), strings.Join(b64, "\n") + "\n"} {
+		if keys, err = ParseVaultKeys(content); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !slices.Equal(keys.KeysB64, b64) || keys.Shares != 0 || keys.Threshold != 0 {
+			t.Fatalf("unexpected keys %+v", keys)
+		}
+	}
+
+	for _, content := range []string{"", "\n\n", "no keys here", `{"unseal_shares":5}`, `{"unseal_keys_b64":`} {
+		if _, err = ParseVaultKeys(content); err == nil {
+			t.Fatalf("%q: expect error", content)
+		}
+	}
+}
+
+func TestDecodeVaultKey(t *testing.T) {
+	share := []byte{0x01, 0xfe, 0x7f, 0x03}
+	for _, key := range []string{hex.EncodeToString(share), base64.StdEncoding.EncodeToString(share)} {
+		decoded, err := decodeVaultKey(key)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !bytes.Equal(decoded, share) {
+			t.Fatalf("%s: expect %x, actual %x", key, share, decoded)
+		}
+	}
+	for _, key := range []string{"not a key", base64.URLEncoding.EncodeToString([]byte{0xfb, 0xff})} {
+		if _, err := decodeVaultKey(key); err == nil {
+			t.Fatalf("%q: expect error", key)
+		}
+	}
+}
+
+func TestImportVault(t *testing.T) {
+	secret := []byte("test vault")
+	b64, hexKeys := vaultKeys(t, secret, 5, 3)
+	keys := []string{b64[4], hexKeys[1], b64[2]}
+	ps, err := ImportVault(keys, 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i, p := range ps {
+		if p.Part != i+1 || p.Parts != 5 || p.Threshold != 3 || len(p.Digest) > 0 || len(p.Set) > 0 || p.Blinding > 0 {
+			t.Fatalf("unexpected part %+v", p)
+		}
+	}
+	recombined, err := Combine(ps)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recombined, secret) {
+		t.Fatalf("expect %q, actual %q", secret, recombined)
+	}
+
+	// Imported parts carry no digest: a wrong threshold gives a wrong secret without an error.
+	wrong, err := ImportVault(keys[:2], 5, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if recombined, err = Combine(wrong); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if bytes.Equal(recombined, secret) {
+		t.Fatalf("expect a wrong secret")
+	}
+
+	short := hex.EncodeToString([]byte{0x01})
+	zero := hex.EncodeToString(append(bytes.Repeat([]byte{0x01}, len(secret)), 0))
+	longer := hex.EncodeToString(append([]byte{0x01}, hexKeysBytes(t, hexKeys[0])...))
+	for name, c := range map[string]struct {
+		keys             []string
+		parts, threshold uint8
+	}{
+		"too many keys":     {b64, 4, 3},
+		"threshold of one":  {keys, 5, 1},
+		"threshold > parts": {keys, 3, 4},
+		"same share":        {[]string{b64[0], hexKeys[0]}, 5, 3},
+		"not a key":         {[]string{b64[0], "not a key"}, 5, 3},
+		"too short":         {[]string{short}, 5, 3},
+		"x of zero":         {[]string{zero}, 5, 3},
+		"other length":      {[]string{b64[1], longer}, 5, 3},
+	} {
+		if _, err = ImportVault(c.keys, c.parts, c.threshold); err == nil {
+			t.Fatalf("%s: expect error", name)
+		}
+	}
+}
+
+func hexKeysBytes(t *testing.T, key string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(key)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return b
+}
+
+func TestExportVault(t *testing.T) {
+	b64, hexKeys := vaultKeys(t, []byte("test vault"), 5, 3)
+	ps, err := ImportVault(b64[:3], 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	names := []string{"a", "b", "c"}
+	keys, err := ExportVault(ps, names)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !slices.Equal(keys.KeysB64, b64[:3]) || !slices.Equal(keys.KeysHex, hexKeys[:3]) ||
+		keys.Shares != 5 || keys.Threshold != 3 {
+		t.Fatalf("unexpected keys %+v", keys)
+	}
+
+	if !slices.Equal(keys.Numbers, []int{1, 2, 3}) {
+		t.Fatalf("unexpected numbers %v", keys.Numbers)
+	}
+
+	// Parts split by fortify carry blinding bytes in their shares, and cannot join unblinded parts.
+	blinded, err := Split([]byte("test"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err = ExportVault([]Part{ps[0], blinded[1]}, names); err == nil {
+		t.Fatalf("expect error")
+	}
+	for name, change := range map[string]func(*Part){
+		"verifiable": func(p *Part) { p.Scheme = SchemeFeldman },
+		"group":      func(p *Part) { p.Groups = "2of3,1of1" },
+		"weighted":   func(p *Part) { p.Points = []string{p.Payload} },
+		"blocks":     func(p *Part) { p.Blocks = 2 },
+	} {
+		parts := slices.Clone(ps)
+		change(&parts[1])
+		if _, err = ExportVault(parts, names); err == nil {
+			t.Fatalf("%s: expect error", name)
+		}
+	}
+}
+
+func TestExportVault_blinded(t *testing.T) {
+	secret := []byte("test vault")
+	ps, err := Split(secret, 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	names := []string{"a", "b", "c"}
+	keys, err := ExportVault([]Part{ps[4], ps[0], ps[2]}, names)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// The secret is split anew, without its blinding bytes, into the unseal keys of all the parts.
+	if len(keys.KeysB64) != 5 || len(keys.KeysHex) != 5 || keys.Shares != 5 || keys.Threshold != 3 ||
+		!slices.Equal(keys.Numbers, []int{1, 2, 3, 4, 5}) {
+		t.Fatalf("unexpected keys %+v", keys)
+	}
+	share, err := base64.StdEncoding.DecodeString(keys.KeysB64[0])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(share) != len(secret)+1 {
+		t.Fatalf("expect a share of %d bytes, actual %d", len(secret)+1, len(share))
+	}
+	imported, err := ImportVault([]string{keys.KeysB64[3], keys.KeysHex[1], keys.KeysB64[4]}, 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	recombined, err := Combine(imported)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recombined, secret) {
+		t.Fatalf("expect %q, actual %q", secret, recombined)
+	}
+
+	gs, err := SplitGroups(secret, 2, []Group{{3, 2}, {2, 2}})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for name, parts := range map[string][]Part{
+		"too few":  ps[:2],
+		"tampered": {ps[0], ps[1], corruptPart(ps[2])},
+		"groups":   append(gs[0][:2], gs[1]...),
+	} {
+		if _, err = ExportVault(parts, names); err == nil {
+			t.Fatalf("%s: expect error", name)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package sss
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wangkang/fortify/utils"
+)
+
+// Weight returns the number of share points the part counts for toward the threshold.
+func (p Part) Weight() int {
+	return 1 + len(p.Points)
+}
+
+// totalWeight returns the number of share points the parts count for toward the threshold.
+func totalWeight(parts []Part) (n int) {
+	for _, p := range parts {
+		n += p.Weight()
+	}
+	return
+}
+
+// ParseWeights parses the weights of the parts written as comma separated numbers, e.g. "2,1,1,1".
+func ParseWeights(s string) ([]uint8, error) {
+	var weights []uint8
+	for _, i := range strings.Split(s, ",") {
+		w, err := strconv.ParseUint(strings.TrimSpace(i), 10, 8)
+		if err != nil || w == 0 {
+			return nil, fmt.Errorf("invalid weight %q, expect a number between 1 and 255", i)
+		}
+		weights = append(weights, uint8(w))
+	}
+	return weights, nil
+}
+
+// FormatWeights writes weights in the form parsed by ParseWeights.
+func FormatWeights(weights []uint8) string {
+	s := make([]string, len(weights))
+	for i, w := range weights {
+		s[i] = strconv.Itoa(int(w))
+	}
+	return strings.Join(s, ",")
+}
+
+// SplitWeighted splits the secret like Split, but the part of holder i carries weights[i] share
+// points, and so counts for weights[i] parts toward the threshold.
+func SplitWeighted(secret []byte, weights []uint8, threshold uint8) ([]Part, error) {
+	raw, digest, err := blind(secret)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.Wipe(raw)
+	return splitWeighted(raw, digest, blindingSize, weights, threshold)
+}
+
+func splitWeighted(raw []byte, digest string, blinding int, weights []uint8, threshold uint8) ([]Part, error) {
+	if len(weights) == 0 {
+		return nil, errors.New("no weights of the parts")
+	}
+	total := 0
+	for _, w := range weights {
+		if w == 0 {
+			return nil, errors.New("weight of a part must be at least 1")
+		}
+		total += int(w)
+	}
+	if total > 255 {
+		return nil, fmt.Errorf("weights of the parts sum up to %d, which cannot exceed 255", total)
+	}
+	points, err := split(raw, digest, blinding, "", uint8(total), threshold, "")
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]Part, len(weights))
+	next := 0
+	for i, w := range weights {
+		parts[i] = points[next]
+		parts[i].Part = i + 1
+		parts[i].Parts = uint8(len(weights))
+		parts[i].Weights = FormatWeights(weights)
+		for _, p := range points[next+1 : next+int(w)] {
+			parts[i].Points = append(parts[i].Points, p.Payload)
+		}
+		next += int(w)
+	}
+	return parts, nil
+}
@@ -0,0 +1,71 @@
+package sss
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSplitWeighted(t *testing.T) {
+	secret := []byte("test weights")
+	weights, err := ParseWeights("2, 1,1,1")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if s := FormatWeights(weights); s != "2,1,1,1" {
+		t.Fatalf("expect 2,1,1,1, actual %s", s)
+	}
+	ps, err := SplitWeighted(secret, weights, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(ps) != 4 || ps[0].Weight() != 2 || ps[1].Weight() != 1 || totalWeight(ps) != 5 {
+		t.Fatalf("expect 4 parts of weights 2,1,1,1, actual %d parts of total weight %d", len(ps), totalWeight(ps))
+	}
+	for i, p := range ps {
+		if p.Part != i+1 || p.Parts != 4 || p.Threshold != 3 || p.Weights != "2,1,1,1" {
+			t.Fatalf("unexpected part %+v", p)
+		}
+	}
+	for _, parts := range [][]Part{
+		{ps[0], ps[1]},
+		{ps[3], ps[0]},
+		{ps[1], ps[2], ps[3]},
+		ps,
+	} {
+		recombined, err := Combine(parts)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !bytes.Equal(recombined, secret) {
+			t.Fatalf("expect %q, actual %q", secret, recombined)
+		}
+	}
+
+	// The heaviest part alone, and light parts short of the threshold
+	if _, err = Combine(ps[:1]); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err = Combine(ps[2:]); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestSplitWeighted_invalid(t *testing.T) {
+	for _, s := range []string{"", "0", "1,,1", "256", "-1", "a"} {
+		if _, err := ParseWeights(s); err == nil {
+			t.Fatalf("%q: expect error", s)
+		}
+	}
+	if _, err := SplitWeighted([]byte("test"), nil, 2); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err := SplitWeighted([]byte("test"), []uint8{2, 0}, 2); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err := SplitWeighted([]byte("test"), []uint8{200, 100}, 2); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err := SplitWeighted([]byte("test"), []uint8{1, 1}, 3); err == nil {
+		t.Fatalf("expect error")
+	}
+}
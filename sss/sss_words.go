@@ -0,0 +1,413 @@
+package sss
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// wordsEnglish is the BIP39 English word list, in which every word is identified by its first
+// four letters.
+//
+//go:embed words_english.txt
+var wordsEnglish string
+
+var (
+	wordList  = strings.Fields(wordsEnglish)
+	wordIndex = func() map[string]int {
+		m := make(map[string]int, len(wordList))
+		for i, w := range wordList {
+			m[w] = i
+		}
+		return m
+	}()
+)
+
+const (
+	wordsVersion      = 2
+	wordsHeaderSize   = 14
+	wordsDigestSize   = 4
+	wordsSetMacSize   = 8
+	wordsChecksumSize = 4
+	wordBits          = 11
+	wordsPerLine      = 6
+)
+
+// EncodeWords encodes a single block part as words of the BIP39 English word list, to be kept on
+// paper. The words carry the set ID, part number, number of parts, threshold, the first bytes of the
+// secret digest and of the set authenticator, the share payload and a checksum, which lets
+// DecodeWords point out a mistyped word. The whole digest is restored from the combined secret, and
+// checked against the bytes carried, as the set ID is. Parts without a digest, such as imported
+// ones, are encoded in version 1 without these bytes, and then nothing detects a wrong reconstruction.
+func EncodeWords(p Part) ([]string, error) {
+	if err := unsupported([]Part{p}, "encoding as words"); err != nil {
+		return nil, err
+	}
+	if p.Part < 1 || p.Part > 255 {
+		return nil, fmt.Errorf("part number %d cannot be encoded as words", p.Part)
+	}
+	if p.Blinding < 0 || p.Blinding > 255 {
+		return nil, fmt.Errorf("secret blinding size %d cannot be encoded as words", p.Blinding)
+	}
+	payload, err := base64.URLEncoding.DecodeString(p.Payload)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) > 255 {
+		return nil, fmt.Errorf("secret share of %d bytes is too long to be encoded as words", len(payload))
+	}
+	set := make([]byte, 8)
+	if len(p.Set) > 0 {
+		var id []byte
+		if id, err = hex.DecodeString(p.Set); err != nil || len(id) != len(set) {
+			return nil, fmt.Errorf("secret share set %q cannot be encoded as words", p.Set)
+		}
+		copy(set, id)
+	}
+	check, mac := p.digestCheck, p.setMacCheck
+	if digest, err := base64.URLEncoding.DecodeString(p.Digest); err == nil && len(digest) >= wordsDigestSize {
+		check = digest[:wordsDigestSize]
+	}
+	if m, err := base64.URLEncoding.DecodeString(p.SetMac); err == nil && len(m) >= wordsSetMacSize {
+		mac = m[:wordsSetMacSize]
+	}
+	data := []byte{wordsVersion}
+	data = append(data, set...)
+	data = append(data, byte(p.Part), p.Parts, p.Threshold, byte(p.Blinding))
+	if len(check) > 0 {
+		// Parts without a set authenticator carry zero bytes in its place.
+		data = append(data, check...)
+		data = append(data, mac...)
+		data = append(data, make([]byte, wordsSetMacSize-len(mac))...)
+	} else {
+		data[0] = 1
+	}
+	data = append(data, byte(len(payload)))
+	data = append(data, payload...)
+	sum := sha256.Sum256(data)
+	data = append(data, sum[:wordsChecksumSize]...)
+
+	words := make([]string, (len(data)*8+wordBits-1)/wordBits)
+	for i := range words {
+		index := 0
+		for b := i * wordBits; b < (i+1)*wordBits; b++ {
+			index <<= 1
+			if b/8 < len(data) {
+				index |= int(data[b/8]>>(7-b%8)) & 1
+			}
+		}
+		words[i] = wordList[index]
+	}
+	return words, nil
+}
+
+// DecodeWords decodes a part encoded by EncodeWords. An error names the word that is not in the
+// word list, or, if the checksum does not match, the word that is most likely mistyped.
+func DecodeWords(words []string) (Part, error) {
+	if len(words) == 0 {
+		return Part{}, errors.New("no words of a secret share")
+	}
+	indexes := make([]int, len(words))
+	var errs []error
+	for i, w := range words {
+		if index, ok := wordIndex[w]; ok {
+			indexes[i] = index
+		} else if suggestion := suggestWord(w); len(suggestion) > 0 {
+			errs = append(errs, fmt.Errorf("word %d %q is not in the word list, did you mean %q?", i+1, w, suggestion))
+		} else {
+			errs = append(errs, fmt.Errorf("word %d %q is not in the word list", i+1, w))
+		}
+	}
+	if len(errs) > 0 {
+		return Part{}, errors.Join(errs...)
+	}
+	data, err := wordsToData(indexes)
+	if err == nil {
+		return dataToPart(data), nil
+	}
+	if hint := locateMistypedWord(words, indexes); hint != nil {
+		return Part{}, hint
+	}
+	return Part{}, err
+}
+
+var errWordsChecksum = errors.New("checksum of the words does not match, a word is mistyped or out of order")
+
+// wordsToData unpacks the word indexes and checks their length and checksum.
+func wordsToData(indexes []int) ([]byte, error) {
+	bits := len(indexes) * wordBits
+	data := make([]byte, bits/8)
+	for b := 0; b < len(data)*8; b++ {
+		if indexes[b/wordBits]>>(wordBits-1-b%wordBits)&1 == 1 {
+			data[b/8] |= 1 << (7 - b%8)
+		}
+	}
+	if len(data) < wordsHeaderSize+wordsChecksumSize {
+		return nil, fmt.Errorf("a secret share has at least %d words, got %d",
+			((wordsHeaderSize+wordsChecksumSize)*8+wordBits-1)/wordBits, len(indexes))
+	}
+	header := wordsHeader(data[0])
+	if header == 0 || len(data) < header+wordsChecksumSize {
+		return nil, errWordsChecksum
+	}
+	size := header + int(data[header-1]) + wordsChecksumSize
+	if expect := (size*8 + wordBits - 1) / wordBits; expect != len(indexes) {
+		return nil, fmt.Errorf("the secret share has %d words, expected %d: a word is missing or extra",
+			len(indexes), expect)
+	}
+	for b := size * 8; b < bits; b++ {
+		if indexes[b/wordBits]>>(wordBits-1-b%wordBits)&1 == 1 {
+			return nil, errWordsChecksum
+		}
+	}
+	data = data[:size]
+	sum := sha256.Sum256(data[:size-wordsChecksumSize])
+	if !bytes.Equal(sum[:wordsChecksumSize], data[size-wordsChecksumSize:]) {
+		return nil, errWordsChecksum
+	}
+	return data, nil
+}
+
+// wordsHeader returns the size of the header of the words version, or 0 for an unknown version.
+// Version 2 adds the first bytes of the secret digest and of the set authenticator to the header
+// of version 1.
+func wordsHeader(version byte) int {
+	switch version {
+	case 1:
+		return wordsHeaderSize
+	case 2:
+		return wordsHeaderSize + wordsDigestSize + wordsSetMacSize
+	default:
+		return 0
+	}
+}
+
+func dataToPart(data []byte) Part {
+	header := wordsHeader(data[0])
+	p := Part{
+		Block:     1,
+		Blocks:    1,
+		Part:      int(data[9]),
+		Parts:     data[10],
+		Threshold: data[11],
+		Blinding:  int(data[12]),
+		Payload:   base64.URLEncoding.EncodeToString(data[header : len(data)-wordsChecksumSize]),
+	}
+	if header > wordsHeaderSize {
+		check := data[wordsHeaderSize-1 : header-1]
+		p.digestCheck = slices.Clone(check[:wordsDigestSize])
+		if mac := check[wordsDigestSize:]; !bytes.Equal(mac, make([]byte, len(mac))) {
+			p.setMacCheck = slices.Clone(mac)
+		}
+	}
+	if set := data[1:9]; !bytes.Equal(set, make([]byte, len(set))) {
+		p.Set = hex.EncodeToString(set)
+	}
+	return p
+}
+
+// locateMistypedWord tries every word of the list at every position, every swap of two neighbouring
+// words, and every missing or extra word, and returns a hint if exactly one of them makes the
+// checksum match.
+func locateMistypedWord(words []string, indexes []int) error {
+	var hints []error
+	candidate := make([]int, len(indexes))
+	for i := range indexes {
+		copy(candidate, indexes)
+		for index := range wordList {
+			if index == indexes[i] {
+				continue
+			}
+			candidate[i] = index
+			if _, err := wordsToData(candidate); err == nil {
+				hints = append(hints, fmt.Errorf("word %d %q is mistyped, it should probably be %q",
+					i+1, words[i], wordList[index]))
+			}
+		}
+	}
+	for i := 0; i+1 < len(indexes); i++ {
+		if indexes[i] == indexes[i+1] {
+			continue
+		}
+		copy(candidate, indexes)
+		candidate[i], candidate[i+1] = candidate[i+1], candidate[i]
+		if _, err := wordsToData(candidate); err == nil {
+			hints = append(hints, fmt.Errorf("words %d %q and %d %q are probably swapped",
+				i+1, words[i], i+2, words[i+1]))
+		}
+	}
+	for i := range indexes {
+		candidate = append(slices.Clone(indexes[:i]), indexes[i+1:]...)
+		if _, err := wordsToData(candidate); err == nil {
+			hints = append(hints, fmt.Errorf("word %d %q is extra", i+1, words[i]))
+		}
+	}
+	for i := 0; i <= len(indexes); i++ {
+		candidate = slices.Insert(slices.Clone(indexes), i, 0)
+		for index := range wordList {
+			candidate[i] = index
+			if _, err := wordsToData(candidate); err == nil {
+				hints = append(hints, fmt.Errorf("word %d is missing, it should probably be %q", i+1, wordList[index]))
+			}
+		}
+	}
+	if len(hints) != 1 {
+		return nil
+	}
+	return hints[0]
+}
+
+// suggestWord returns the only word of the list sharing the first four letters with w, or the
+// only word one edit away from w.
+func suggestWord(w string) string {
+	var found []string
+	if len(w) >= 4 {
+		for _, i := range wordList {
+			if strings.HasPrefix(i, w[:4]) {
+				found = append(found, i)
+			}
+		}
+		if len(found) == 1 {
+			return found[0]
+		}
+	}
+	found = found[:0]
+	for _, i := range wordList {
+		if oneEditAway(w, i) {
+			found = append(found, i)
+		}
+	}
+	if len(found) == 1 {
+		return found[0]
+	}
+	return ""
+}
+
+func oneEditAway(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(b)-len(a) > 1 {
+		return false
+	}
+	i := 0
+	for i < len(a) && a[i] == b[i] {
+		i++
+	}
+	if len(a) == len(b) {
+		return i < len(a) && a[i+1:] == b[i+1:]
+	}
+	return a[i:] == b[i+1:]
+}
+
+// ParseWords splits the text of a words file into words, skipping comments after '#' and the
+// numbers in front of the words.
+func ParseWords(text string) []string {
+	var words []string
+	for _, line := range strings.Split(text, "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		for _, w := range strings.Fields(strings.ToLower(line)) {
+			w = strings.TrimLeft(w, "0123456789.:)")
+			if len(w) > 0 {
+				words = append(words, w)
+			}
+		}
+	}
+	return words
+}
+
+// IsWords reports whether the content of a key part file is words rather than JSON: letters, with
+// the numbers and punctuation of numbered lines, and comments after '#'.
+func IsWords(content []byte) bool {
+	content = bytes.TrimSpace(content)
+	if len(content) == 0 || IsKitText(content) {
+		return false
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		for _, r := range line {
+			if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || strings.ContainsRune(" \t\r.:)", r)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// WriteWordsFile writes the words of a part as numbered lines, to be printed or copied by hand.
+func WriteWordsFile(p Part, path string, truncate bool) error {
+	words, err := EncodeWords(p)
+	if err != nil {
+		return err
+	}
+	var file *os.File
+	if file, err = OpenFileForWrite(path, truncate); err != nil {
+		return err
+	}
+	if err = file.Truncate(0); err != nil {
+		return err
+	}
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "# fortify secret share %d of %d, threshold %d", p.Part, p.Parts, p.Threshold)
+	if len(p.Set) > 0 {
+		_, _ = fmt.Fprintf(&b, ", set %s", p.Set)
+	}
+	b.WriteString("\n")
+	if len(p.Holder) > 0 {
+		_, _ = fmt.Fprintf(&b, "# holder: %s\n", p.Holder)
+	}
+	if len(p.Labels) > 0 {
+		_, _ = fmt.Fprintf(&b, "# labels: %s\n", strings.Join(p.Labels, ", "))
+	}
+	if p.NotAfter != nil {
+		_, _ = fmt.Fprintf(&b, "# not after: %s\n", p.NotAfter.Format(time.DateOnly))
+	}
+	_, _ = fmt.Fprintf(&b, "# %d words\n", len(words))
+	for i := 0; i < len(words); i += wordsPerLine {
+		var line strings.Builder
+		for j, w := range words[i:min(i+wordsPerLine, len(words))] {
+			_, _ = fmt.Fprintf(&line, "%2d. %-10s", i+j+1, w)
+		}
+		b.WriteString(strings.TrimRight(line.String(), " ") + "\n")
+	}
+	_, err = file.WriteString(b.String())
+	return err
+}
+
+// readWordsFromStdin reads the words or recovery kit text of one part typed or pasted on the
+// standard input, ending with an empty line.
+func readWordsFromStdin(stdin *bufio.Reader, number int) (string, error) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Printf("Enter the words or recovery kit text of secret share %d, then an empty line:\n", number)
+	}
+	var text strings.Builder
+	for {
+		line, err := stdin.ReadString('\n')
+		text.WriteString(line)
+		if len(strings.TrimSpace(line)) == 0 && len(ParseWords(text.String())) > 0 {
+			break
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return "", err
+		}
+	}
+	return text.String(), nil
+}
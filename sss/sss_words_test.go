@@ -0,0 +1,143 @@
+package sss
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestEncodeWords(t *testing.T) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ps, err := Split(secret, 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var decoded []Part
+	for _, p := range ps {
+		words, err := EncodeWords(p)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		q, err := DecodeWords(words)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if q.Part != p.Part || q.Parts != p.Parts || q.Threshold != p.Threshold || q.Blinding != p.Blinding ||
+			q.Set != p.Set || q.Payload != p.Payload {
+			t.Fatalf("expect %+v, actual %+v", p, q)
+		}
+		if len(q.Digest) > 0 || len(q.digestCheck) != wordsDigestSize {
+			t.Fatalf("part %d: bad digest %q, check %x", q.Part, q.Digest, q.digestCheck)
+		}
+		decoded = append(decoded, q)
+	}
+	parts := []Part{decoded[2], decoded[0]}
+	if err = restoreDigest(parts); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if parts[0].Digest != ps[0].Digest || parts[1].Digest != ps[0].Digest {
+		t.Fatalf("expect digest %s, actual %s", ps[0].Digest, parts[0].Digest)
+	}
+	recombined, err := Combine(parts)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recombined, secret) {
+		t.Fatalf("expect %x, actual %x", secret, recombined)
+	}
+}
+
+func TestRestoreDigest_mixed(t *testing.T) {
+	ps, err := Split([]byte("test"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	words, err := EncodeWords(ps[1])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	q, err := DecodeWords(words)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// Words along with a part read from JSON, as CombineKeyFiles reads them
+	parts := []Part{ps[0], q}
+	if err = restoreDigest(parts); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if parts[1].Digest != ps[0].Digest {
+		t.Fatalf("expect digest %s, actual %s", ps[0].Digest, parts[1].Digest)
+	}
+	if _, err = Combine(parts); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestEncodeWords_noDigest(t *testing.T) {
+	ps, err := Split([]byte("test"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	p := ps[0]
+	p.Digest = ""
+	words, err := EncodeWords(p)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	q, err := DecodeWords(words)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if q.Payload != p.Payload || q.digestCheck != nil {
+		t.Fatalf("expect %+v, actual %+v", p, q)
+	}
+}
+
+func TestRestoreDigest_wrongWords(t *testing.T) {
+	ps, err := Split([]byte("test"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	others, err := Split([]byte("TEST"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// A share of another secret, under the set of the first, reconstructs a wrong secret.
+	forged := others[1]
+	forged.Set, forged.SetMac, forged.Digest = ps[1].Set, ps[1].SetMac, ps[1].Digest
+	var parts []Part
+	for _, p := range []Part{ps[0], forged} {
+		words, err := EncodeWords(p)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		q, err := DecodeWords(words)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		parts = append(parts, q)
+	}
+	if err = restoreDigest(parts); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestIsWords(t *testing.T) {
+	for content, expect := range map[string]bool{
+		"# fortify secret share 1 of 3\n 1. across     2. erupt\n": true,
+		"abandon ability able":         true,
+		`{"payload":"AAAA","block":1}`: false,
+		`"payload":"AAAA","block":1}`:  false,
+		"payload: AAAA=":               false,
+		"FORTIFY:SSS:ABCDEF":           false,
+		"":                             false,
+		"\x00\x01\x02":                 false,
+	} {
+		if actual := IsWords([]byte(content)); actual != expect {
+			t.Fatalf("%q: expect %v, actual %v", content, expect, actual)
+		}
+	}
+}
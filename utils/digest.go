@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"crypto/hmac"
 	"crypto/sha512"
 	"encoding/base64"
 )
@@ -12,3 +13,11 @@ func ComputeDigest(slice []byte) string {
 	digest := base64.URLEncoding.EncodeToString(sha.Sum(nil))
 	return digest
 }
+
+// ComputeKeyedDigest computes the HMAC-SHA512 digest of a byte slice under a key. Unlike
+// ComputeDigest, it cannot be used to confirm a guessed slice without knowing the key.
+func ComputeKeyedDigest(key, slice []byte) string {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(slice)
+	return base64.URLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// PassphraseSource tells ReadPassphrase where to read passphrases from without a terminal.
+// At most one source may be configured; the terminal prompt is the fallback when none is.
+type PassphraseSource struct {
+	File    string // path of a file whose first line is the passphrase
+	Fd      int    // open file descriptor to read one passphrase line from per request, -1 if unused
+	Env     string // name of an environment variable holding the passphrase
+	Askpass string // external program printing the passphrase, invoked with the prompt as argument
+}
+
+var (
+	passphraseSource     = PassphraseSource{Fd: -1}
+	passphraseFdReader   *bufio.Reader
+	passphraseSourceLock sync.Mutex
+)
+
+// SetPassphraseSource replaces the source used by subsequent ReadPassphrase calls. It fails when
+// more than one source is configured, rather than silently using one of them.
+func SetPassphraseSource(s PassphraseSource) error {
+	if s.sources() > 1 {
+		return errors.New("only one passphrase source may be given: " +
+			"--passphrase-file, --passphrase-fd, --passphrase-env or --askpass")
+	}
+	passphraseSourceLock.Lock()
+	defer passphraseSourceLock.Unlock()
+	passphraseSource = s
+	passphraseFdReader = nil
+	return nil
+}
+
+// sources returns the number of sources configured.
+func (s PassphraseSource) sources() (n int) {
+	for _, configured := range []bool{len(s.File) > 0, s.Fd >= 0, len(s.Env) > 0, len(s.Askpass) > 0} {
+		if configured {
+			n++
+		}
+	}
+	return
+}
+
+// ReadPassphrase reads a passphrase from the configured source, or prompts on the terminal.
+func ReadPassphrase(prompt string) ([]byte, error) {
+	passphraseSourceLock.Lock()
+	defer passphraseSourceLock.Unlock()
+	s := passphraseSource
+	switch {
+	case len(s.File) > 0:
+		return readPassphraseFile(s.File)
+	case s.Fd >= 0:
+		return readPassphraseFd(s.Fd)
+	case len(s.Env) > 0:
+		if v, ok := os.LookupEnv(s.Env); ok {
+			return []byte(v), nil
+		}
+		return nil, fmt.Errorf("passphrase environment variable %s is not set", s.Env)
+	case len(s.Askpass) > 0:
+		return readPassphraseAskpass(s.Askpass, prompt)
+	default:
+		return readPassphraseTerminal(prompt)
+	}
+}
+
+// SinglePassphrase reports whether the configured source gives the same passphrase on every read,
+// as a passphrase file or environment variable does.
+func SinglePassphrase() bool {
+	passphraseSourceLock.Lock()
+	defer passphraseSourceLock.Unlock()
+	return len(passphraseSource.File) > 0 || len(passphraseSource.Env) > 0
+}
+
+// ReadNewPassphrase reads a passphrase about to protect something, like ReadPassphrase. When
+// prompting on the terminal, it asks for the passphrase twice, to catch typing mistakes.
+func ReadNewPassphrase(prompt string) ([]byte, error) {
+	passphraseSourceLock.Lock()
+	s := passphraseSource
+	passphraseSourceLock.Unlock()
+	if s.sources() > 0 {
+		return ReadPassphrase(prompt)
+	}
+	passphrase, err := readPassphraseTerminal(prompt)
+	if err != nil {
+		return nil, err
+	}
+	var again []byte
+	if again, err = readPassphraseTerminal("Repeat passphrase: "); err != nil {
+		Wipe(passphrase)
+		return nil, err
+	}
+	defer Wipe(again)
+	if !bytes.Equal(passphrase, again) {
+		Wipe(passphrase)
+		return nil, errors.New("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+func readPassphraseFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading passphrase file failed: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	return readPassphraseLine(bufio.NewReader(f))
+}
+
+func readPassphraseFd(fd int) ([]byte, error) {
+	if passphraseFdReader == nil {
+		f := os.NewFile(uintptr(fd), fmt.Sprintf("passphrase-fd-%d", fd))
+		if f == nil {
+			return nil, fmt.Errorf("invalid passphrase file descriptor: %d", fd)
+		}
+		passphraseFdReader = bufio.NewReader(f)
+	}
+	return readPassphraseLine(passphraseFdReader)
+}
+
+func readPassphraseLine(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadBytes('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && len(line) > 0) {
+		return nil, fmt.Errorf("reading passphrase failed: %v", err)
+	}
+	return bytes.TrimRight(line, "\r\n"), nil
+}
+
+func readPassphraseAskpass(program, prompt string) ([]byte, error) {
+	cmd := exec.Command(program, strings.TrimSpace(prompt))
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("askpass program %s failed: %v", program, err)
+	}
+	return bytes.TrimRight(out, "\r\n"), nil
+}
+
+func readPassphraseTerminal(prompt string) ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, errors.New("cannot prompt for passphrase: standard input is not a terminal " +
+			"(use --passphrase-file, --passphrase-fd, --passphrase-env or --askpass)")
+	}
+	fmt.Print(prompt)
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return nil, fmt.Errorf("reading passphrase failed: %v", err)
+	}
+	return passphrase, nil
+}
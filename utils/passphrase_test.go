@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadPassphrase(t *testing.T) {
+	t.Cleanup(func() { _ = SetPassphraseSource(PassphraseSource{Fd: -1}) })
+	dir := t.TempDir()
+	file := filepath.Join(dir, "passphrase")
+	if err := os.WriteFile(file, []byte("from file\r\nsecond line\n"), 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	askpass := filepath.Join(dir, "askpass")
+	if err := os.WriteFile(askpass, []byte("#!/bin/sh\necho \"from askpass: $1\"\n"), 0700); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	if _, err = w.WriteString("from fd\nsecond from fd"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	_ = w.Close()
+	t.Setenv("FORTIFY_TEST_PASSPHRASE", "from env")
+
+	for _, c := range []struct {
+		name   string
+		source PassphraseSource
+		expect []string
+	}{
+		{"file", PassphraseSource{File: file, Fd: -1}, []string{"from file", "from file"}},
+		{"fd", PassphraseSource{Fd: int(r.Fd())}, []string{"from fd", "second from fd"}},
+		{"env", PassphraseSource{Env: "FORTIFY_TEST_PASSPHRASE", Fd: -1}, []string{"from env", "from env"}},
+		{"askpass", PassphraseSource{Askpass: askpass, Fd: -1}, []string{"from askpass: Enter passphrase:"}},
+	} {
+		if err = SetPassphraseSource(c.source); err != nil {
+			t.Fatalf("%s: err: %v", c.name, err)
+		}
+		for _, expect := range c.expect {
+			passphrase, err := ReadPassphrase("Enter passphrase: ")
+			if err != nil {
+				t.Fatalf("%s: err: %v", c.name, err)
+			}
+			if string(passphrase) != expect {
+				t.Fatalf("%s: expect %q, actual %q", c.name, expect, passphrase)
+			}
+		}
+	}
+
+	for _, c := range []struct {
+		name   string
+		source PassphraseSource
+	}{
+		{"no file", PassphraseSource{File: filepath.Join(dir, "none"), Fd: -1}},
+		{"fd at its end", PassphraseSource{Fd: int(r.Fd())}},
+		{"unset env", PassphraseSource{Env: "FORTIFY_TEST_UNSET", Fd: -1}},
+		{"failing askpass", PassphraseSource{Askpass: filepath.Join(dir, "none"), Fd: -1}},
+		// The standard input of tests is not a terminal.
+		{"terminal", PassphraseSource{Fd: -1}},
+	} {
+		if err = SetPassphraseSource(c.source); err != nil {
+			t.Fatalf("%s: err: %v", c.name, err)
+		}
+		if _, err = ReadPassphrase("Enter passphrase: "); err == nil {
+			t.Fatalf("%s: expect error", c.name)
+		}
+	}
+}
+
+func TestSetPassphraseSource_several(t *testing.T) {
+	t.Cleanup(func() { _ = SetPassphraseSource(PassphraseSource{Fd: -1}) })
+	file := filepath.Join(t.TempDir(), "passphrase")
+	if err := os.WriteFile(file, []byte("from file\n"), 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := SetPassphraseSource(PassphraseSource{File: file, Fd: -1}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for name, source := range map[string]PassphraseSource{
+		"file and fd":      {File: file, Fd: 0},
+		"fd and env":       {Fd: 0, Env: "HOME"},
+		"env and askpass":  {Env: "HOME", Askpass: "true", Fd: -1},
+		"file and askpass": {File: file, Askpass: "true", Fd: -1},
+		"all":              {File: file, Fd: 0, Env: "HOME", Askpass: "true"},
+	} {
+		if err := SetPassphraseSource(source); err == nil {
+			t.Fatalf("%s: expect error", name)
+		}
+	}
+	// The source set before is kept.
+	passphrase, err := ReadPassphrase("Enter passphrase: ")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(passphrase) != "from file" {
+		t.Fatalf("expect %q, actual %q", "from file", passphrase)
+	}
+	if !SinglePassphrase() {
+		t.Fatalf("expect a single passphrase from a file")
+	}
+}
@@ -0,0 +1,54 @@
+package utils
+
+import "runtime"
+
+// SecureBuffer holds key material. Where supported, its memory is locked against being swapped
+// to disk and excluded from core dumps. Destroy wipes the memory before releasing it. Copies derived
+// from the key material by other packages, such as the key schedule of crypto/aes, are not covered.
+type SecureBuffer struct {
+	data []byte
+	free func([]byte)
+}
+
+// NewSecureBuffer allocates a zeroed buffer of the given size, falling back to ordinary heap
+// memory when locked memory is unavailable.
+func NewSecureBuffer(size int) *SecureBuffer {
+	if data, free, err := allocLocked(size); err == nil {
+		return &SecureBuffer{data: data, free: free}
+	}
+	return &SecureBuffer{data: make([]byte, size)}
+}
+
+// NewSecureBufferFrom moves b into a new secure buffer and wipes b.
+func NewSecureBufferFrom(b []byte) *SecureBuffer {
+	s := NewSecureBuffer(len(b))
+	copy(s.data, b)
+	Wipe(b)
+	return s
+}
+
+func (s *SecureBuffer) Bytes() []byte {
+	if s == nil {
+		return nil
+	}
+	return s.data
+}
+
+// Destroy wipes and releases the buffer. It is safe to call more than once.
+func (s *SecureBuffer) Destroy() {
+	if s == nil || s.data == nil {
+		return
+	}
+	Wipe(s.data)
+	if s.free != nil {
+		s.free(s.data)
+	}
+	s.data = nil
+	s.free = nil
+}
+
+// Wipe overwrites b with zeros.
+func Wipe(b []byte) {
+	clear(b)
+	runtime.KeepAlive(b)
+}
@@ -0,0 +1,27 @@
+//go:build linux
+
+package utils
+
+import "golang.org/x/sys/unix"
+
+// allocLocked maps anonymous pages of its own for the buffer, so that unlocking it never
+// unlocks memory shared with other allocations.
+func allocLocked(size int) ([]byte, func([]byte), error) {
+	if size == 0 {
+		return []byte{}, nil, nil
+	}
+	data, err := unix.Mmap(-1, 0, size, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANONYMOUS)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = unix.Mlock(data); err != nil {
+		_ = unix.Munmap(data)
+		return nil, nil, err
+	}
+	_ = unix.Madvise(data, unix.MADV_DONTDUMP)
+	free := func(b []byte) {
+		_ = unix.Munlock(b)
+		_ = unix.Munmap(b)
+	}
+	return data, free, nil
+}
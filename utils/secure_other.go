@@ -0,0 +1,9 @@
+//go:build !linux
+
+package utils
+
+import "errors"
+
+func allocLocked(int) ([]byte, func([]byte), error) {
+	return nil, nil, errors.New("locked memory is unsupported on this platform")
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSecureBuffer(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	b := bytes.Clone(key)
+	s := NewSecureBufferFrom(b)
+	if !bytes.Equal(b, make([]byte, len(b))) {
+		t.Fatalf("expect the source wiped, actual %q", b)
+	}
+	if !bytes.Equal(s.Bytes(), key) {
+		t.Fatalf("expect %q, actual %q", key, s.Bytes())
+	}
+
+	// The memory is wiped before it is released, whether locked or not.
+	released := 0
+	free := s.free
+	s.free = func(data []byte) {
+		released++
+		if !bytes.Equal(data, make([]byte, len(key))) {
+			t.Fatalf("expect the buffer wiped before release, actual %q", data)
+		}
+		if free != nil {
+			free(data)
+		}
+	}
+	s.Destroy()
+	if released != 1 {
+		t.Fatalf("expect the buffer released once, actual %d", released)
+	}
+
+	// Bytes and Destroy are safe after Destroy, and on no buffer at all.
+	if s.Bytes() != nil {
+		t.Fatalf("expect no bytes after destroy, actual %q", s.Bytes())
+	}
+	s.Destroy()
+	if released != 1 {
+		t.Fatalf("expect the buffer released once, actual %d", released)
+	}
+	var none *SecureBuffer
+	if none.Bytes() != nil {
+		t.Fatalf("expect no bytes")
+	}
+	none.Destroy()
+}
+
+func TestSecureBuffer_heap(t *testing.T) {
+	// The buffer of ordinary heap memory used where locked memory is unavailable
+	data := []byte("key material")
+	s := &SecureBuffer{data: data}
+	s.Destroy()
+	if !bytes.Equal(data, make([]byte, len(data))) {
+		t.Fatalf("expect the buffer wiped, actual %q", data)
+	}
+	if s.Bytes() != nil {
+		t.Fatalf("expect no bytes after destroy, actual %q", s.Bytes())
+	}
+
+	empty := NewSecureBuffer(0)
+	if len(empty.Bytes()) != 0 {
+		t.Fatalf("expect an empty buffer, actual %q", empty.Bytes())
+	}
+	empty.Destroy()
+}